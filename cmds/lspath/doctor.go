@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// severity is how bad a finding is.
+type severity int
+
+const (
+	warning severity = iota // untidy, but harmless
+	problem                 // wrong, or a security risk
+)
+
+func (s severity) String() string {
+	if s == problem {
+		return "error"
+	}
+	return "warning"
+}
+
+// A finding is something wrong with an entry in a path list.
+type finding struct {
+	sev   severity
+	index int // of the entry, from 1
+	entry string
+	msg   string
+}
+
+// docLists are path lists of documentation, not of code to run.
+// An empty entry in one stands for the system's default list, and
+// a relative one, though it depends on the current directory, is
+// no risk.
+var docLists = map[string]bool{"MANPATH": true, "INFOPATH": true}
+
+// diagnose checks each of dirs, the path list name split into
+// entries, and returns what it finds, in order.
+func diagnose(name string, dirs []string) []finding {
+	var (
+		found []finding
+		seen  = make(map[string]int) // clean entry to its index
+	)
+	relative := problem
+	if docLists[name] {
+		relative = warning
+	}
+	for i, d := range dirs {
+		add := func(sev severity, format string, args ...any) {
+			found = append(found, finding{sev, i + 1, d, fmt.Sprintf(format, args...)})
+		}
+
+		switch {
+		case d == "" && docLists[name]:
+			// the default list
+		case d == "":
+			add(problem, "empty entry: means the current directory")
+		case !filepath.IsAbs(d):
+			add(relative, "relative entry: depends on the current directory")
+		}
+
+		clean := filepath.Clean(d)
+		if d == "" && docLists[name] {
+			clean = "" // not the current directory
+		}
+		if j, ok := seen[clean]; ok {
+			switch prev := dirs[j-1]; {
+			case prev == d:
+				add(warning, "duplicate of entry %d", j)
+			case strings.TrimRight(prev, "/") == strings.TrimRight(d, "/"):
+				add(warning, "same as entry %d but for a trailing slash", j)
+			default:
+				add(warning, "same directory as entry %d, %s", j, prev)
+			}
+			continue
+		}
+		seen[clean] = i + 1
+
+		if d == "" || !filepath.IsAbs(d) {
+			continue
+		}
+		fi, err := os.Stat(d)
+		switch {
+		case os.IsNotExist(err):
+			add(warning, "does not exist")
+		case err != nil:
+			add(warning, "%v", err)
+		case !fi.IsDir():
+			add(problem, "not a directory")
+		case fi.Mode().Perm()&0002 != 0:
+			add(problem, "world-writable: anyone can add to it")
+		}
+	}
+	return found
+}
+
+// printFindings prints found as a table, one finding per row.
+func printFindings(w io.Writer, found []finding) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, f := range found {
+		entry := f.entry
+		if entry == "" {
+			entry = `""`
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", f.sev, f.index, entry, f.msg)
+	}
+	return tw.Flush()
+}
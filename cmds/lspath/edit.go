@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// edits are the commands that print a new path list, made from
+// the current one and the command's dir arguments, if it takes any.
+var edits = map[string]struct {
+	takesDirs bool
+	do        func(dirs, args []string) []string
+}{
+	"dedupe":  {false, func(dirs, _ []string) []string { return dedupe(dirs) }},
+	"prepend": {true, prepend},
+	"append":  {true, appendMissing},
+	"remove":  {true, remove},
+	"prune":   {false, func(dirs, _ []string) []string { return prune(dirs) }},
+}
+
+// same reports whether entries a and b name the same directory,
+// spelled the same but for things like a trailing slash.
+func same(a, b string) bool { return filepath.Clean(a) == filepath.Clean(b) }
+
+// index returns the index of the first entry in dirs that is the
+// same as d, or -1.
+func index(dirs []string, d string) int {
+	for i, x := range dirs {
+		if same(x, d) {
+			return i
+		}
+	}
+	return -1
+}
+
+// dedupe returns dirs less every entry that repeats an earlier one.
+func dedupe(dirs []string) []string {
+	var out []string
+	for _, d := range dirs {
+		if index(out, d) < 0 {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// prepend returns dirs with each of add that is missing from it put
+// first, in the order given.  One already there is not moved.
+func prepend(dirs, add []string) []string {
+	var front []string
+	for _, d := range add {
+		if index(dirs, d) < 0 && index(front, d) < 0 {
+			front = append(front, d)
+		}
+	}
+	return append(front, dirs...)
+}
+
+// appendMissing returns dirs with each of add that is missing from
+// it put last, in the order given.  One already there is not moved.
+func appendMissing(dirs, add []string) []string {
+	out := append([]string(nil), dirs...)
+	for _, d := range add {
+		if index(out, d) < 0 {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// remove returns dirs less every entry that is the same as one of
+// drop.
+func remove(dirs, drop []string) []string {
+	var out []string
+	for _, d := range dirs {
+		if index(drop, d) < 0 {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// prune returns dirs less the entries that aren't directories,
+// mostly ones that don't exist.  An empty entry, the current
+// directory, is kept.
+func prune(dirs []string) []string {
+	var out []string
+	for _, d := range dirs {
+		if d != "" {
+			if fi, err := os.Stat(d); err != nil || !fi.IsDir() {
+				continue
+			}
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// formatList returns dirs as the value of the variable name, for
+// shell: "" for the bare value, as for $(lspath dedupe), or sh,
+// csh or fish for a command that sets it.
+func formatList(dirs []string, name, shell string) (string, error) {
+	value := strings.Join(dirs, string(os.PathListSeparator))
+	switch shell {
+	case "":
+		return value, nil
+	case "sh":
+		return fmt.Sprintf("export %s=%s", name, shQuote(value)), nil
+	case "csh":
+		return fmt.Sprintf("setenv %s %s", name, shQuote(value)), nil
+	case "fish":
+		s := "set -gx " + name
+		for _, d := range dirs {
+			s += " " + fishQuote(d)
+		}
+		return s, nil
+	}
+	return "", fmt.Errorf("bad shell %q; want sh, csh or fish", shell)
+}
+
+// shQuote single-quotes s for sh and csh.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s for fish, which escapes within them.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// canExecute reports whether fi is a regular file with any of its
+// execute bits set; there is no owner to check it against.
+func canExecute(fi os.FileInfo) bool {
+	return fi.Mode().IsRegular() && fi.Mode().Perm()&0111 != 0
+}
@@ -0,0 +1,53 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// canExecute reports whether the current user may run the file fi
+// describes: it is a regular file, and the execute bit for the
+// owner, group or others, whichever the user is, is set.  Root
+// needs any one of them.
+func canExecute(fi os.FileInfo) bool {
+	return mayExecute(fi, os.Geteuid(), inGroup)
+}
+
+// mayExecute is canExecute for the user uid, in the groups
+// inGroup reports.
+func mayExecute(fi os.FileInfo, uid int, inGroup func(gid int) bool) bool {
+	if !fi.Mode().IsRegular() {
+		return false
+	}
+	perm := fi.Mode().Perm()
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return perm&0111 != 0
+	}
+
+	switch {
+	case uid == 0:
+		return perm&0111 != 0
+	case int(st.Uid) == uid:
+		return perm&0100 != 0
+	case inGroup(int(st.Gid)):
+		return perm&0010 != 0
+	}
+	return perm&0001 != 0
+}
+
+// inGroup reports whether the current user is in group gid.
+func inGroup(gid int) bool {
+	if gid == os.Getegid() {
+		return true
+	}
+	groups, _ := os.Getgroups()
+	for _, g := range groups {
+		if g == gid {
+			return true
+		}
+	}
+	return false
+}
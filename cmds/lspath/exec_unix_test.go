@@ -0,0 +1,51 @@
+//go:build unix
+
+package main
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeInfo describes a file with mode, owned by uid and gid.
+type fakeInfo struct {
+	mode     fs.FileMode
+	uid, gid uint32
+}
+
+func (fi fakeInfo) Name() string       { return "fake" }
+func (fi fakeInfo) Size() int64        { return 0 }
+func (fi fakeInfo) Mode() fs.FileMode  { return fi.mode }
+func (fi fakeInfo) ModTime() time.Time { return time.Time{} }
+func (fi fakeInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fakeInfo) Sys() any           { return &syscall.Stat_t{Uid: fi.uid, Gid: fi.gid} }
+
+func TestMayExecute(t *testing.T) {
+	const uid, gid = 1000, 100
+	inGroup := func(g int) bool { return g == gid }
+
+	for _, tc := range []struct {
+		fi   os.FileInfo
+		uid  int
+		want bool
+		why  string
+	}{
+		{fakeInfo{0755, uid, gid}, uid, true, "owner"},
+		{fakeInfo{0655, uid, gid}, uid, false, "owner, without the owner bit"},
+		{fakeInfo{0011, uid, gid}, uid, false, "owner, with only group and other bits"},
+		{fakeInfo{0750, 1, gid}, uid, true, "group member"},
+		{fakeInfo{0701, 1, gid}, uid, false, "group member, without the group bit"},
+		{fakeInfo{0751, 1, 1}, uid, true, "other"},
+		{fakeInfo{0750, 1, 1}, uid, false, "other, without the other bit"},
+		{fakeInfo{0700, 1, 1}, 0, true, "root, with any bit"},
+		{fakeInfo{0644, 1, 1}, 0, false, "root, with no bits"},
+		{fakeInfo{fs.ModeDir | 0755, uid, gid}, uid, false, "a dir"},
+	} {
+		if got := mayExecute(tc.fi, tc.uid, inGroup); got != tc.want {
+			t.Errorf("%s: mayExecute(%v) = %t; want %t", tc.why, tc.fi.Mode(), got, tc.want)
+		}
+	}
+}
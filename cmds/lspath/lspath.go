@@ -1,30 +1,79 @@
 package main
 
 // List path, and optionally files, or pattern-matched files, in
-// each path component.
+// each path component, or the executables that shadow others, or
+// where commands are found, or what is wrong with it.
 
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+
+	"zacharysyoung/CLUtils/pkg/cli"
 )
 
 var files = flag.Bool("f", false, "list files inside dirs")
 var fPattern = flag.String("re", "", "regex[] pattern of files to match; turns on -f")
+var shadow = flag.Bool("shadow", false, "list executables found in more than one dir, and which copy wins")
+var doctor = flag.Bool("doctor", false, "check the path list for problems, and exit with status 1 if any are errors")
+var varName = flag.String("var", "PATH", "the path-list environment `variable` to look at, e.g., MANPATH")
+var stdin = flag.Bool("stdin", false, "read the path list from stdin, instead of the environment")
+var shell = flag.String("shell", "", "with an edit command, print a command that sets the list for `shell`: sh, csh or fish")
+var long = flag.Bool("l", false, "with -shadow or names, print each copy's size and modification time")
 
 func usage() {
-	fmt.Fprintln(os.Stderr, `usage: lspath [-f | -re]
+	fmt.Fprintln(os.Stderr, `usage: lspath [-var name | -stdin] [-f | -re]
+       lspath [-var name | -stdin] -shadow [-l] [-re]
+       lspath [-var name | -stdin] [-l] name...
+       lspath [-var name | -stdin] -doctor
+       lspath [-var name | -stdin] [-shell sh|csh|fish] dedupe | prune
+       lspath [-var name | -stdin] [-shell sh|csh|fish] prepend | append | remove dir...
+
+Parses $PATH env var and prints the directories, optionally printing files in those directories.
+
+With -var, parses another colon-separated path list instead, like MANPATH,
+LD_LIBRARY_PATH, PKG_CONFIG_PATH, GOPATH or PYTHONPATH.  With -stdin, reads
+the list from stdin, e.g., one captured from another machine; a leading
+NAME= or export NAME=, and quotes around the list, are dropped.  For lists
+other than PATH, -shadow and names count every entry in a directory, not
+only the ones the user can run.
+
+With -shadow, prints each executable name found in more than one directory,
+and each copy in PATH order: the first wins, and shadows the rest.  A copy
+that is the winner by another path, through a symlink, is marked same file.
+-re narrows the names looked at.
+
+With names, prints every copy of each name in PATH order, like which -a,
+marking the one that wins.  Only files the current user can run count, by
+their permissions for the user as owner, group member or other.  Exits with
+status 1 if any name is not found.
+
+With -doctor, prints what is wrong with each PATH entry, with a severity.
+Errors are empty entries and relative ones, which mean or depend on the
+current directory, entries that are not directories, and world-writable
+directories.  Warnings are entries that don't exist, and duplicates,
+including ones that differ only by a trailing slash.  In MANPATH and
+INFOPATH, an empty entry stands for the default list, and is fine, and a
+relative entry is only a warning.  Exits with status 1 if there are any
+errors.
 
-Parses $PATH env var and prints the directories, optionally printing files in those directories.`)
+The edit commands print a new list: dedupe drops entries that repeat an
+earlier one; prepend and append add each dir that is missing, in order,
+at the start or the end; remove drops each dir; prune drops entries that
+aren't existing directories.  Entries are the same if they differ only by
+a trailing slash and the like.  The list is printed as is, for
+export PATH=$(lspath dedupe), or with -shell, as a command to eval that
+sets it.  To look up a name that is also an edit command, put -- before it.`)
 	flag.PrintDefaults()
-	os.Exit(2)
+	os.Exit(cli.ExitUsage)
 }
 
 func main() {
-	flag.Usage = usage
-	flag.Parse()
+	cli.Parse("lspath", usage)
 
 	var (
 		reFpat *regexp.Regexp
@@ -34,12 +83,104 @@ func main() {
 		*files = true
 		reFpat, err = regexp.Compile(*fPattern)
 		if err != nil {
-			fmt.Fprintln(os.Stderr, "couldn't compile -fpat, bad regexp")
-			os.Exit(1)
+			cli.BadArgs(fmt.Sprintf("couldn't compile -re: %v", err))
+		}
+	}
+
+	list := os.Getenv(*varName)
+	if *stdin {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			cli.ErrorOut(err.Error())
+		}
+		list = trimList(string(b), *varName)
+	}
+	dirs := filepath.SplitList(list)
+
+	keep := canExecute
+	if *varName != "PATH" {
+		keep = func(os.FileInfo) bool { return true }
+	}
+
+	if *doctor {
+		if *files || *shadow || *long || flag.NArg() > 0 {
+			cli.BadArgs("-doctor goes alone")
+		}
+		found := diagnose(*varName, dirs)
+		if err := printFindings(os.Stdout, found); err != nil {
+			cli.ErrorOut(err.Error())
+		}
+		for _, f := range found {
+			if f.sev == problem {
+				os.Exit(cli.ExitFailure)
+			}
+		}
+		return
+	}
+
+	if e, ok := edits[flag.Arg(0)]; ok && !afterDashes() {
+		if *files || *shadow || *long {
+			cli.BadArgs(flag.Arg(0) + " can't go with -f, -re, -shadow or -l")
+		}
+		args := flag.Args()[1:]
+		if e.takesDirs != (len(args) > 0) {
+			if e.takesDirs {
+				cli.BadArgs(flag.Arg(0) + " needs at least one dir")
+			}
+			cli.BadArgs(flag.Arg(0) + " takes no arguments")
+		}
+		s, err := formatList(e.do(dirs, args), *varName, *shell)
+		if err != nil {
+			cli.BadArgs(err.Error())
+		}
+		fmt.Println(s)
+		return
+	}
+	if *shell != "" {
+		cli.BadArgs("-shell needs an edit command")
+	}
+
+	if names := flag.Args(); len(names) > 0 {
+		if *files || *shadow {
+			cli.BadArgs("names can't go with -f, -re or -shadow")
+		}
+		var (
+			found  []string
+			copies = make(map[string][]exe)
+		)
+		for _, name := range names {
+			exes := lookup(dirs, name, keep)
+			if len(exes) == 0 {
+				cli.Error(fmt.Sprintf("%s: not found in %s", name, *varName))
+				continue
+			}
+			found, copies[name] = append(found, name), exes
+		}
+		if err := printCopies(os.Stdout, found, copies, *long); err != nil {
+			cli.ErrorOut(err.Error())
+		}
+		if len(found) < len(names) {
+			os.Exit(cli.ExitFailure)
 		}
+		return
 	}
 
-	dirs := strings.Split(os.Getenv("PATH"), ":")
+	if *shadow {
+		var kept []string
+		for _, d := range dirs {
+			if !ignore(d) {
+				kept = append(kept, d)
+			}
+		}
+		names, copies := shadows(kept, reFpat, keep, func(err error) { cli.Warn(err.Error()) })
+		if err := printCopies(os.Stdout, names, copies, *long); err != nil {
+			cli.ErrorOut(err.Error())
+		}
+		return
+	}
+	if *long {
+		cli.BadArgs("-l needs -shadow or names")
+	}
 
 	toPrint := make([]string, 0)
 	for _, d := range dirs {
@@ -52,7 +193,7 @@ func main() {
 		if *files {
 			dirEntries, err := os.ReadDir(d)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "error: ", err)
+				cli.Warn(err.Error())
 			}
 
 			for _, f := range dirEntries {
@@ -78,6 +219,29 @@ func main() {
 	}
 }
 
+// afterDashes reports whether the arguments left after flags
+// followed a "--", which makes an edit command's name a name to
+// look up.
+func afterDashes() bool {
+	i := len(os.Args) - flag.NArg() - 1
+	return i > 0 && os.Args[i] == "--"
+}
+
+// trimList returns the path list in s, less the surrounding
+// space, NAME= or export NAME= for the variable name, and quotes
+// it may have been captured with.
+func trimList(s, name string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "export ")
+	s = strings.TrimPrefix(s, name+"=")
+	for _, q := range []string{`"`, "'"} {
+		if len(s) >= 2 && strings.HasPrefix(s, q) && strings.HasSuffix(s, q) {
+			s = s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
 // ignore ignores dirs I don't actually care about
 func ignore(d string) bool {
 	switch {
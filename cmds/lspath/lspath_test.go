@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// mkexes makes a dir under root for each key of files, holding its
+// files, each with mode.
+func mkexes(t *testing.T, root string, files map[string]map[string]os.FileMode) {
+	for dir, names := range files {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for name, mode := range names {
+			path := filepath.Join(root, dir, name)
+			if err := os.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, mode); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestShadows(t *testing.T) {
+	root := t.TempDir()
+	mkexes(t, root, map[string]map[string]os.FileMode{
+		"a": {"python": 0755, "go": 0755, "only-a": 0755},
+		"b": {"python": 0755, "go": 0644, "cc": 0755},
+		"c": {"python": 0700, "cc": 0755},
+	})
+	if err := os.Symlink("a", filepath.Join(root, "a2")); err != nil {
+		t.Fatal(err)
+	}
+	dirs := []string{filepath.Join(root, "a"), filepath.Join(root, "b"), filepath.Join(root, "missing"), filepath.Join(root, "a2"), filepath.Join(root, "c")}
+
+	var warnings int
+	names, copies := shadows(dirs, nil, canExecute, func(error) { warnings++ })
+	if want := []string{"cc", "python"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("shadows names = %v; want %v", names, want)
+	}
+	if warnings != 1 {
+		t.Errorf("shadows warned %d times; want once, for the missing dir", warnings)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := printCopies(buf, names, copies, false); err != nil {
+		t.Fatal(err)
+	}
+	want := strings.NewReplacer("ROOT", root).Replace(`cc
+  wins      ROOT/b/cc
+  shadowed  ROOT/c/cc
+python
+  wins       ROOT/a/python
+  shadowed   ROOT/b/python
+  same file  ROOT/a2/python
+  shadowed   ROOT/c/python
+`)
+	if buf.String() != want {
+		t.Errorf("printCopies\ngot\n%s\nwant\n%s", buf, want)
+	}
+
+	names, _ = shadows(dirs, regexp.MustCompile("^c"), canExecute, func(error) {})
+	if want := []string{"cc"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("shadows matching ^c names = %v; want %v", names, want)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	root := t.TempDir()
+	mkexes(t, root, map[string]map[string]os.FileMode{
+		"a": {"go": 0755, "text": 0644},
+		"b": {"go": 0755},
+	})
+	a, b := filepath.Join(root, "a"), filepath.Join(root, "b")
+	dirs := []string{a, filepath.Join(root, "missing"), b, ""}
+
+	for _, tc := range []struct {
+		name string
+		want []string
+	}{
+		{"go", []string{a, b}},
+		{"text", nil},
+		{"nope", nil},
+		{filepath.Join(b, "go"), []string{b}},
+	} {
+		var got []string
+		for _, e := range lookup(dirs, tc.name, canExecute) {
+			got = append(got, e.dir)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("lookup(%s) found it in %v; want %v", tc.name, got, tc.want)
+		}
+	}
+
+	t.Chdir(b)
+	if got := lookup([]string{a, ""}, "go", canExecute); len(got) != 2 || got[1].dir != "." {
+		t.Errorf("lookup with an empty dir = %v; want the current dir second", got)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	root := t.TempDir()
+	mkexes(t, root, map[string]map[string]os.FileMode{
+		"bin":  {"go": 0755},
+		"open": {},
+	})
+	var (
+		bin  = filepath.Join(root, "bin")
+		open = filepath.Join(root, "open")
+		file = filepath.Join(bin, "go")
+	)
+	if err := os.Chmod(open, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	type want struct {
+		sev   severity
+		index int
+	}
+	var got []want
+	found := diagnose("PATH", []string{bin, "", "bin", bin + "/", open, filepath.Join(root, "missing"), file, bin, root + "//bin"})
+	for _, f := range found {
+		got = append(got, want{f.sev, f.index})
+	}
+	wants := []want{
+		{problem, 2}, // empty
+		{problem, 3}, // relative
+		{warning, 4}, // trailing slash
+		{problem, 5}, // world-writable
+		{warning, 6}, // missing
+		{problem, 7}, // not a dir
+		{warning, 8}, // duplicate
+		{warning, 9}, // spelled differently
+	}
+	if !reflect.DeepEqual(got, wants) {
+		buf := &bytes.Buffer{}
+		printFindings(buf, found)
+		t.Errorf("diagnose got\n%s\nwant severities and indexes %v", buf, wants)
+	}
+
+	if found := diagnose("PATH", []string{bin}); len(found) > 0 {
+		t.Errorf("diagnose found a problem with a healthy entry: %+v", found[0])
+	}
+}
+
+// TestDiagnoseVar checks that -doctor judges empty and relative
+// entries by the list, as -var names it: in MANPATH, an empty
+// entry is the default list.
+func TestDiagnoseVar(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name  string
+		dirs  []string
+		wants []severity
+	}{
+		{"PATH", []string{"", dir}, []severity{problem}},
+		{"LD_LIBRARY_PATH", []string{dir, "lib"}, []severity{problem}},
+		{"MANPATH", []string{"", dir}, nil},
+		{"MANPATH", []string{dir, "", "man"}, []severity{warning}},
+		{"MANPATH", []string{"", "."}, []severity{warning}},
+		{"INFOPATH", []string{dir, ""}, nil},
+	} {
+		var got []severity
+		for _, f := range diagnose(tc.name, tc.dirs) {
+			got = append(got, f.sev)
+		}
+		if !reflect.DeepEqual(got, tc.wants) {
+			t.Errorf("-var %s: diagnose(%q) found %v; want %v", tc.name, tc.dirs, got, tc.wants)
+		}
+	}
+}
+
+func TestTrimList(t *testing.T) {
+	for _, in := range []string{
+		"/a::/b",
+		"/a::/b\n",
+		"MANPATH=/a::/b",
+		`export MANPATH="/a::/b"`,
+		"MANPATH='/a::/b'\n",
+	} {
+		if got := trimList(in, "MANPATH"); got != "/a::/b" {
+			t.Errorf("trimList(%q) = %q; want %q", in, got, "/a::/b")
+		}
+	}
+	if got := trimList("PATH=/a", "MANPATH"); got != "PATH=/a" {
+		t.Errorf("trimList dropped another variable's name: %q", got)
+	}
+}
+
+func TestEdits(t *testing.T) {
+	root := t.TempDir()
+	dirs := []string{"/a", "/b", "/a/", "", "/c", "/b"}
+
+	for _, tc := range []struct {
+		cmd  string
+		args []string
+		want []string
+	}{
+		{"dedupe", nil, []string{"/a", "/b", "", "/c"}},
+		{"prepend", []string{"/x", "/b", "/y", "/x"}, []string{"/x", "/y", "/a", "/b", "/a/", "", "/c", "/b"}},
+		{"append", []string{"/x", "/c/", "/y"}, []string{"/a", "/b", "/a/", "", "/c", "/b", "/x", "/y"}},
+		{"remove", []string{"/a", "/b/"}, []string{"", "/c"}},
+		{"prune", nil, []string{""}},
+	} {
+		if got := edits[tc.cmd].do(dirs, tc.args); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s %v of %q = %q; want %q", tc.cmd, tc.args, dirs, got, tc.want)
+		}
+	}
+
+	if got := prune([]string{root, filepath.Join(root, "missing")}); !reflect.DeepEqual(got, []string{root}) {
+		t.Errorf("prune kept %v; want only %s", got, root)
+	}
+	if dirs[0] != "/a" || len(dirs) != 6 {
+		t.Errorf("an edit changed its input: %q", dirs)
+	}
+}
+
+func TestFormatList(t *testing.T) {
+	dirs := []string{"/a", "/it's"}
+	for _, tc := range []struct {
+		shell, want string
+	}{
+		{"", "/a:/it's"},
+		{"sh", `export PATH='/a:/it'\''s'`},
+		{"csh", `setenv PATH '/a:/it'\''s'`},
+		{"fish", `set -gx PATH '/a' '/it\'s'`},
+	} {
+		got, err := formatList(dirs, "PATH", tc.shell)
+		if err != nil || got != tc.want {
+			t.Errorf("formatList for %q = %s, %v; want %s", tc.shell, got, err, tc.want)
+		}
+	}
+	if _, err := formatList(dirs, "PATH", "zsh"); err == nil {
+		t.Errorf("formatList for zsh didn't error")
+	}
+}
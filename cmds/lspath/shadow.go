@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"text/tabwriter"
+)
+
+// An exe is an executable found in a PATH directory, or, for
+// other path lists, any entry found in one of its directories.
+type exe struct {
+	dir, name string
+	info      os.FileInfo // of the file the path resolves to
+}
+
+func (e exe) path() string { return filepath.Join(e.dir, e.name) }
+
+// entries returns the entries in dir that keep is true for, sorted
+// by name.  Keep is given what a symlink resolves to.  An empty
+// dir is the current directory.
+func entries(dir string, keep func(os.FileInfo) bool) ([]exe, error) {
+	if dir == "" {
+		dir = "."
+	}
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var exes []exe
+	for _, e := range des {
+		fi, err := os.Stat(filepath.Join(dir, e.Name()))
+		if err != nil || !keep(fi) {
+			continue
+		}
+		exes = append(exes, exe{dir, e.Name(), fi})
+	}
+	return exes, nil
+}
+
+// shadows walks dirs in order, and returns each name, of entries
+// keep is true for, matching re, if not nil, that is in more than
+// one of them, with
+// its copies in the same order: the first wins, and shadows the
+// rest.  A name whose copies are all the same file, e.g., with
+// /bin a symlink to /usr/bin, shadows nothing and is left out.
+// Unreadable dirs are warned about and passed over.
+func shadows(dirs []string, re *regexp.Regexp, keep func(os.FileInfo) bool, warn func(error)) (names []string, copies map[string][]exe) {
+	copies = make(map[string][]exe)
+	for _, d := range dirs {
+		exes, err := entries(d, keep)
+		if err != nil {
+			warn(err)
+			continue
+		}
+		for _, e := range exes {
+			if re != nil && !re.MatchString(e.name) {
+				continue
+			}
+			copies[e.name] = append(copies[e.name], e)
+		}
+	}
+
+	for name, exes := range copies {
+		if shadowing(exes) {
+			names = append(names, name)
+		} else {
+			delete(copies, name)
+		}
+	}
+	sort.Strings(names)
+	return names, copies
+}
+
+// shadowing reports whether any of exes is a different file from
+// the first.
+func shadowing(exes []exe) bool {
+	for _, e := range exes[1:] {
+		if !os.SameFile(e.info, exes[0].info) {
+			return true
+		}
+	}
+	return false
+}
+
+// printCopies prints each name and its copies, marking which one
+// wins and which are shadowed.  A copy that is the same file as
+// the winner, through a symlink, is marked as such; it hides
+// nothing.  Long adds each copy's size and modification time.
+func printCopies(w io.Writer, names []string, copies map[string][]exe, long bool) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintln(tw, name)
+		exes := copies[name]
+		for i, e := range exes {
+			mark := "shadowed"
+			switch {
+			case i == 0:
+				mark = "wins"
+			case os.SameFile(e.info, exes[0].info):
+				mark = "same file"
+			}
+			if long {
+				fmt.Fprintf(tw, "  %s\t%s\t%d\t%s\n", mark, e.path(), e.info.Size(), e.info.ModTime().Format("2006-01-02 15:04"))
+			} else {
+				fmt.Fprintf(tw, "  %s\t%s\n", mark, e.path())
+			}
+		}
+	}
+	return tw.Flush()
+}
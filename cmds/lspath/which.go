@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// lookup returns each copy of name in dirs that keep is true for,
+// in order, so with canExecute, the first is the one a shell would
+// run.
+// As in a shell, an empty dir is the current directory, and a
+// name with a slash in it is looked up as is, not in dirs.
+func lookup(dirs []string, name string, keep func(os.FileInfo) bool) []exe {
+	if strings.ContainsRune(name, '/') {
+		dirs, name = []string{filepath.Dir(name)}, filepath.Base(name)
+	}
+
+	var exes []exe
+	for _, d := range dirs {
+		if d == "" {
+			d = "."
+		}
+		fi, err := os.Stat(filepath.Join(d, name))
+		if err != nil || !keep(fi) {
+			continue
+		}
+		exes = append(exes, exe{d, name, fi})
+	}
+	return exes
+}
@@ -22,6 +22,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"zacharysyoung/CLUtils/pkg/cli"
 )
 
 var first = flag.Bool("first", false, "print first occurrence of NAME and stop")
@@ -29,12 +31,11 @@ var first = flag.Bool("first", false, "print first occurrence of NAME and stop")
 func usage() {
 	fmt.Fprintln(os.Stderr, "usage: searchup [-h] [-first] NAME")
 	flag.PrintDefaults()
-	os.Exit(2)
+	os.Exit(cli.ExitUsage)
 }
 
 func main() {
-	flag.Usage = usage
-	flag.Parse()
+	cli.Parse("searchup", usage)
 
 	if len(flag.Args()) != 1 {
 		usage()
@@ -43,15 +44,15 @@ func main() {
 
 	path, err := os.Getwd()
 	if err != nil {
-		errorExit("could not get working directory", err)
+		cli.ErrorOut(fmt.Sprintf("could not get working directory: %v", err))
 	}
 
 	found, err := searchUp(path, name, *first)
 	if err != nil {
-		errorExit("", err)
+		cli.ErrorOut(err.Error())
 	}
 	if found == nil {
-		os.Exit(1)
+		os.Exit(cli.ExitFailure)
 	}
 	fmt.Fprintln(os.Stdout, strings.Join(found, "\n"))
 }
@@ -96,14 +97,3 @@ func search(path string, name string) (bool, error) {
 
 	return false, nil
 }
-
-func errorExit(msg string, err error) {
-	switch {
-	case msg != "" && err != nil:
-		msg = fmt.Sprintf("%s: %v", msg, err)
-	case err != nil:
-		msg = err.Error()
-	}
-	fmt.Fprintf(os.Stderr, "error: %s\n", msg)
-	os.Exit(1)
-}
@@ -16,6 +16,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"zacharysyoung/CLUtils/pkg/cli"
 )
 
 var (
@@ -28,19 +30,18 @@ var (
 func usage() {
 	fmt.Fprintln(os.Stderr, "usage: tree [-h] [options] PATH")
 	flag.PrintDefaults()
-	os.Exit(2)
+	os.Exit(cli.ExitUsage)
 }
 
 func main() {
-	flag.Usage = usage
-	flag.Parse()
+	cli.Parse("tree", usage)
 
 	if len(flag.Args()) != 1 {
 		usage()
 	}
 
 	if err := printTree(flag.Arg(0), os.Stdout); err != nil {
-		errorExit(err.Error())
+		cli.ErrorOut(err.Error())
 	}
 }
 
@@ -87,8 +88,3 @@ func getDepth(path string) (depth int, err error) {
 	}
 	return
 }
-
-func errorExit(msg string) {
-	fmt.Fprintf(os.Stderr, "error: %s\n", msg)
-	os.Exit(1)
-}
@@ -0,0 +1,118 @@
+// Package cli is the runtime shared by the commands: flag
+// parsing with -v and -version, and error reporting with
+// consistent exit codes.
+//
+// Usage errors, like bad flags or arguments, exit with status
+// ExitUsage.  Errors in doing the work exit with ExitFailure.
+// Either way the message goes to stderr, prefixed "error: ".
+// Warnings, for problems a command carries on past, are prefixed
+// "warning: ", and don't change the exit status.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+// Exit statuses.
+const (
+	ExitFailure = 1 // the work failed
+	ExitUsage   = 2 // the command line was bad
+)
+
+// Name is the command's name, as set by Parse.
+var Name = filepath.Base(os.Args[0])
+
+// Parse sets Name to name and flag.Usage to usage, then parses
+// the command line.  If -v or -version is set, it prints the
+// version to stdout and exits.
+func Parse(name string, usage func()) {
+	Name = name
+	v := flag.Bool("v", false, "print version/build info")
+	flag.BoolVar(v, "version", false, "print version/build info")
+	flag.Usage = usage
+	flag.Parse()
+
+	if *v {
+		fmt.Println(Version())
+		os.Exit(0)
+	}
+}
+
+// Version returns Name's version from its build info.
+func Version() string {
+	bi, _ := debug.ReadBuildInfo()
+	return version(Name, bi)
+}
+
+func version(name string, bi *debug.BuildInfo) string {
+	var (
+		goVer string
+
+		rev      string
+		t        time.Time
+		modified bool
+	)
+	if bi != nil {
+		goVer = bi.GoVersion
+		for _, x := range bi.Settings {
+			if x.Key == "vcs.revision" {
+				rev = x.Value
+				if len(rev) > 7 {
+					rev = rev[:7] // short hash
+				}
+			}
+			if x.Key == "vcs.time" {
+				t, _ = time.Parse(time.RFC3339, x.Value)
+				t = t.Local()
+			}
+			if x.Key == "vcs.modified" {
+				modified = x.Value == "true"
+			}
+		}
+	}
+
+	var s string
+	switch modified {
+	case true:
+		s += "go:           " + goVer + "\n"
+		s += "vcs.revision: " + rev + "\n"
+		s += "vcs.time:     " + t.Format(time.RFC3339)
+	default:
+		s = name + ":" + rev + ":" + goVer
+	}
+
+	return s
+}
+
+// stderr is where messages go.
+var stderr io.Writer = os.Stderr
+
+// Warn prints s as a warning, for a problem the command carries
+// on past, like a file it skips.
+func Warn(s string) {
+	fmt.Fprintf(stderr, "warning: %s\n", s)
+}
+
+// Error prints s as an error, for work that failed, and carries
+// on; the command should still exit with ExitFailure.
+func Error(s string) {
+	fmt.Fprintf(stderr, "error: %s\n", s)
+}
+
+// BadArgs prints s as an error and exits with ExitUsage.
+func BadArgs(s string) {
+	Error(s)
+	os.Exit(ExitUsage)
+}
+
+// ErrorOut prints s as an error and exits with ExitFailure.
+func ErrorOut(s string) {
+	Error(s)
+	os.Exit(ExitFailure)
+}
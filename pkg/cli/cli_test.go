@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	settings := []debug.BuildSetting{
+		{Key: "vcs.revision", Value: "0123456789abcdef"},
+		{Key: "vcs.time", Value: "2024-01-02T03:04:05Z"},
+	}
+
+	bi := &debug.BuildInfo{GoVersion: "go1.24.2", Settings: settings}
+	if got, want := version("tree", bi), "tree:0123456:go1.24.2"; got != want {
+		t.Errorf("version = %q; want %q", got, want)
+	}
+
+	bi.Settings = append(settings, debug.BuildSetting{Key: "vcs.modified", Value: "true"})
+	got := version("tree", bi)
+	for _, want := range []string{"go:           go1.24.2\n", "vcs.revision: 0123456\n", "vcs.time:     "} {
+		if !strings.Contains(got, want) {
+			t.Errorf("modified version = %q; want it to contain %q", got, want)
+		}
+	}
+
+	if got, want := version("tree", nil), "tree::"; got != want {
+		t.Errorf("version without build info = %q; want %q", got, want)
+	}
+}
+
+func TestWarnError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	stderr = buf
+	defer func() { stderr = os.Stderr }()
+
+	Warn("skipping a")
+	Error("b failed")
+	if got, want := buf.String(), "warning: skipping a\nerror: b failed\n"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,375 @@
+// Package eol converts line endings: LF, CRLF and bare CR, the
+// line ending of classic Mac OS.
+//
+// A Transformer does the converting.  It is a
+// golang.org/x/text/transform.SpanningTransformer, so it can be
+// used with that package, or streamed through with this package's
+// Reader and Writer:
+//
+//	w := eol.NewWriter(os.Stdout, eol.CRLF)
+//	io.Copy(w, os.Stdin)
+//	w.Close()
+//
+// converts every line ending from stdin to CRLF.  Name the line
+// endings to replace to convert only some of them:
+//
+//	w := eol.NewWriter(os.Stdout, eol.LF, eol.CRLF)
+//
+// is dos2unix, leaving any bare CRs as they are.  A Transformer
+// can also tidy the ends of lines and of the text as it goes; see
+// Tidy.
+package eol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/text/transform"
+)
+
+// Ending is a kind of line ending.
+type Ending int
+
+const (
+	LF   Ending = iota // "\n"
+	CRLF               // "\r\n"
+	CR                 // "\r", not followed by "\n"
+)
+
+const (
+	cr = '\r'
+	lf = '\n'
+)
+
+var endingBytes = [...][]byte{
+	LF:   {lf},
+	CRLF: {cr, lf},
+	CR:   {cr},
+}
+
+// Bytes returns e as bytes.
+func (e Ending) Bytes() []byte { return endingBytes[e] }
+
+func (e Ending) String() string {
+	return [...]string{"LF", "CRLF", "CR"}[e]
+}
+
+// Parse returns the Ending named by s: lf, crlf or cr.
+func Parse(s string) (Ending, error) {
+	switch s {
+	case "lf":
+		return LF, nil
+	case "crlf":
+		return CRLF, nil
+	case "cr":
+		return CR, nil
+	}
+	return 0, fmt.Errorf("bad line ending %q; want lf, crlf or cr", s)
+}
+
+// The errors Transform and Span return are those of the transform
+// package, so a Transformer works with it as any other does.
+var (
+	// ErrShortDst means Transform needs more room in dst.
+	ErrShortDst = transform.ErrShortDst
+
+	// ErrShortSrc means Transform needs more of src to tell
+	// what comes next: whether a CR starts a CRLF, or the rest
+	// of a UTF-16 code unit.
+	ErrShortSrc = transform.ErrShortSrc
+
+	// ErrEndOfSpan means Span found input Transform would change.
+	ErrEndOfSpan = transform.ErrEndOfSpan
+)
+
+// Transformer replaces line endings.  It keeps no state between
+// calls to Transform, so it can be shared, unless it tidies.
+type Transformer struct {
+	to    Ending
+	from  [3]bool          // the Endings to replace
+	order binary.ByteOrder // nil for 1-byte code units
+	seqs  [3][]byte        // each Ending, encoded
+
+	tidy  Tidy
+	state tidyState
+}
+
+var _ transform.SpanningTransformer = (*Transformer)(nil)
+
+// NewTransformer returns a Transformer that replaces the from
+// line endings, or every line ending if from is empty, with to.
+func NewTransformer(to Ending, from ...Ending) *Transformer {
+	return newTransformer(nil, to, from)
+}
+
+// NewUTF16Transformer is NewTransformer for UTF-16 text, where
+// line endings are made of 2-byte code units, in order, and not
+// of bytes.  It does not read or write a BOM.
+func NewUTF16Transformer(order binary.ByteOrder, to Ending, from ...Ending) *Transformer {
+	return newTransformer(order, to, from)
+}
+
+func newTransformer(order binary.ByteOrder, to Ending, from []Ending) *Transformer {
+	t := &Transformer{to: to, order: order}
+	if len(from) == 0 {
+		from = []Ending{LF, CRLF, CR}
+	}
+	for _, e := range from {
+		t.from[e] = true
+	}
+
+	for e, seq := range endingBytes {
+		if order == nil {
+			t.seqs[e] = seq
+			continue
+		}
+		for _, b := range seq {
+			u := make([]byte, 2)
+			order.PutUint16(u, uint16(b))
+			t.seqs[e] = append(t.seqs[e], u...)
+		}
+	}
+	return t
+}
+
+// width is the size of t's code units.
+func (t *Transformer) width() int {
+	if t.order == nil {
+		return 1
+	}
+	return 2
+}
+
+// unit returns the code unit at the start of b.
+func (t *Transformer) unit(b []byte) uint16 {
+	if t.order == nil {
+		return uint16(b[0])
+	}
+	return t.order.Uint16(b)
+}
+
+// Transform writes to dst the conversion of src, returning the
+// number of bytes written to dst and read from src.  A CR at the
+// end of src is not read unless atEOF, nor is a partial UTF-16
+// code unit.  At EOF, a partial code unit is passed through.
+func (t *Transformer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	if t.tidy != (Tidy{}) {
+		return t.transformTidy(dst, src, atEOF)
+	}
+	if t.order == nil {
+		return t.transformBytes(dst, src, atEOF)
+	}
+
+	w := t.width()
+	for nSrc+w <= len(src) {
+		var (
+			e    Ending
+			n    = w // bytes of src read
+			c    = t.unit(src[nSrc:])
+			next = nSrc + w
+		)
+		switch {
+		case c == lf:
+			e = LF
+		case c == cr && next+w <= len(src) && t.unit(src[next:]) == lf:
+			e, n = CRLF, 2*w
+		case c == cr && next+w > len(src) && !atEOF:
+			return nDst, nSrc, ErrShortSrc
+		case c == cr:
+			e = CR
+		default:
+			if nDst+w > len(dst) {
+				return nDst, nSrc, ErrShortDst
+			}
+			nDst += copy(dst[nDst:], src[nSrc:next])
+			nSrc = next
+			continue
+		}
+
+		if t.from[e] {
+			e = t.to
+		}
+		if nDst+len(t.seqs[e]) > len(dst) {
+			return nDst, nSrc, ErrShortDst
+		}
+		nDst += copy(dst[nDst:], t.seqs[e])
+		nSrc += n
+	}
+
+	if nSrc < len(src) {
+		if !atEOF {
+			return nDst, nSrc, ErrShortSrc
+		}
+		if nDst+len(src)-nSrc > len(dst) {
+			return nDst, nSrc, ErrShortDst
+		}
+		nDst += copy(dst[nDst:], src[nSrc:])
+		nSrc = len(src)
+	}
+	return nDst, nSrc, nil
+}
+
+// transformBytes is Transform for 1-byte code units.  Rather
+// than go a byte at a time, it finds the next line ending with
+// bytes.IndexByte and copies the whole span of text before it.
+func (t *Transformer) transformBytes(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		rest := src[nSrc:]
+		i := t.index(rest)
+
+		var (
+			e    Ending
+			end  = i // of the text before e
+			n    int // bytes of e
+			hold bool
+		)
+		switch {
+		case i < 0:
+			end = len(rest)
+			// a CR at the end might start a CRLF
+			if !atEOF && rest[end-1] == cr {
+				end, hold = end-1, true
+			}
+		case rest[i] == lf && i > 0 && rest[i-1] == cr:
+			e, end, n = CRLF, i-1, 2
+		case rest[i] == lf:
+			e, n = LF, 1
+		case i+1 < len(rest) && rest[i+1] == lf:
+			e, n = CRLF, 2
+		case i+1 == len(rest) && !atEOF:
+			hold = true
+		default:
+			e, n = CR, 1
+		}
+
+		c := copy(dst[nDst:], rest[:end])
+		nDst += c
+		nSrc += c
+		if c < end {
+			return nDst, nSrc, ErrShortDst
+		}
+		if hold {
+			return nDst, nSrc, ErrShortSrc
+		}
+		if n == 0 {
+			continue
+		}
+
+		if t.from[e] {
+			e = t.to
+		}
+		if nDst+len(t.seqs[e]) > len(dst) {
+			return nDst, nSrc, ErrShortDst
+		}
+		nDst += copy(dst[nDst:], t.seqs[e])
+		nSrc += n
+	}
+	return nDst, nSrc, nil
+}
+
+// Span returns the length of the start of src that Transform
+// would leave as it is.  It returns
+// ErrEndOfSpan if Transform would change src[n:], or ErrShortSrc
+// if it needs more of src to tell.  Since tidying a line can
+// depend on the lines after it, a Transformer that tidies spans
+// nothing.
+func (t *Transformer) Span(src []byte, atEOF bool) (n int, err error) {
+	switch {
+	case len(src) == 0:
+		return 0, nil
+	case t.tidy != (Tidy{}):
+		return 0, ErrEndOfSpan
+	case t.order == nil:
+		return t.spanBytes(src, atEOF)
+	}
+
+	w := t.width()
+	for n+w <= len(src) {
+		var (
+			e    Ending
+			size = w
+			c    = t.unit(src[n:])
+			next = n + w
+		)
+		switch {
+		case c == lf:
+			e = LF
+		case c == cr && next+w <= len(src) && t.unit(src[next:]) == lf:
+			e, size = CRLF, 2*w
+		case c == cr && next+w > len(src) && !atEOF:
+			return n, ErrShortSrc
+		case c == cr:
+			e = CR
+		default:
+			n = next
+			continue
+		}
+		if t.changes(e) {
+			return n, ErrEndOfSpan
+		}
+		n += size
+	}
+
+	if n < len(src) && !atEOF {
+		return n, ErrShortSrc
+	}
+	return len(src), nil
+}
+
+// spanBytes is Span for 1-byte code units, going from line
+// ending to line ending, like transformBytes.
+func (t *Transformer) spanBytes(src []byte, atEOF bool) (n int, err error) {
+	for n < len(src) {
+		rest := src[n:]
+		i := t.index(rest)
+
+		var (
+			e     Ending
+			start = i // of e
+			size  int // of e
+		)
+		switch {
+		case i < 0:
+			// a CR at the end might start a CRLF
+			if !atEOF && rest[len(rest)-1] == cr {
+				return len(src) - 1, ErrShortSrc
+			}
+			return len(src), nil
+		case rest[i] == lf && i > 0 && rest[i-1] == cr:
+			e, start, size = CRLF, i-1, 2
+		case rest[i] == lf:
+			e, size = LF, 1
+		case i+1 < len(rest) && rest[i+1] == lf:
+			e, size = CRLF, 2
+		case i+1 == len(rest) && !atEOF:
+			return n + i, ErrShortSrc
+		default:
+			e, size = CR, 1
+		}
+
+		if t.changes(e) {
+			return n + start, ErrEndOfSpan
+		}
+		n += start + size
+	}
+	return n, nil
+}
+
+// changes reports whether t replaces e with another ending.
+func (t *Transformer) changes(e Ending) bool { return t.from[e] && e != t.to }
+
+// index returns the index in b of the next LF, or -1.  If bare
+// CRs are replaced, it stops at CRs too.  Otherwise a CR is only
+// a line ending as part of a CRLF, found by its LF.
+func (t *Transformer) index(b []byte) int {
+	if t.from[CR] {
+		return bytes.IndexAny(b, "\r\n")
+	}
+	return bytes.IndexByte(b, lf)
+}
+
+// Reset readies t for a new text.  It only matters if t tidies;
+// otherwise it does nothing, and is here to round out the
+// transform.Transformer method set.
+func (t *Transformer) Reset() { t.state = tidyState{} }
@@ -0,0 +1,213 @@
+package eol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"golang.org/x/text/transform"
+)
+
+var (
+	x = strings.ReplaceAll
+
+	pre = func(s string) string { return x(x(x(s, "<CRLF>", "\r\n"), "<LF>", "\n"), "<CR>", "\r") }
+)
+
+// widen encodes each byte of s as a 2-byte code unit.
+func widen(s string, order binary.ByteOrder) string {
+	b := make([]byte, 2*len(s))
+	for i := 0; i < len(s); i++ {
+		order.PutUint16(b[2*i:], uint16(s[i]))
+	}
+	return string(b)
+}
+
+var directions = []struct {
+	to, from Ending
+	ins      []string
+	wants    []string // one for each of ins
+}{
+	{LF, CRLF, mixed, []string{"a<LF>b<LF>c<CR>d", "<CR><LF><LF><CR>"}},
+	{CR, CRLF, mixed, []string{"a<CR>b<LF>c<CR>d", "<CR><CR><LF><CR>"}},
+	{CRLF, LF, mixed, []string{"a<CRLF>b<CRLF>c<CR>d", "<CR><CRLF><CRLF><CR>"}},
+	{CR, LF, mixed, []string{"a<CRLF>b<CR>c<CR>d", "<CR><CRLF><CR><CR>"}},
+	{LF, CR, mixed, []string{"a<CRLF>b<LF>c<LF>d", "<LF><CRLF><LF><LF>"}},
+	{CRLF, CR, mixed, []string{"a<CRLF>b<LF>c<CRLF>d", "<CRLF><CRLF><LF><CRLF>"}},
+}
+
+var mixed = []string{
+	"a<CRLF>b<LF>c<CR>d",
+	"<CR><CR><LF><LF><CR>",
+}
+
+func TestTransformDirections(t *testing.T) {
+	for _, tc := range directions {
+		for i, in := range tc.ins {
+			for _, order := range []binary.ByteOrder{nil, binary.LittleEndian, binary.BigEndian} {
+				src, want := pre(in), pre(tc.wants[i])
+				if order != nil {
+					src, want = widen(src, order), widen(want, order)
+				}
+
+				dst := make([]byte, 2*len(src))
+				nDst, nSrc, err := newTransformer(order, tc.to, []Ending{tc.from}).Transform(dst, []byte(src), true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := string(dst[:nDst]); got != want || nSrc != len(src) {
+					t.Errorf("%s to %s, %v\nin   %s\ngot  %q, read %d\nwant %q, read %d",
+						tc.from, tc.to, order, in, got, nSrc, want, len(src))
+				}
+			}
+		}
+	}
+}
+
+func TestTransformAll(t *testing.T) {
+	for _, tc := range []struct {
+		to       Ending
+		in, want string
+	}{
+		{LF, "a<CRLF>b<LF>c<CR>d<CR>", "a<LF>b<LF>c<LF>d<LF>"},
+		{CRLF, "a<CRLF>b<LF>c<CR>d<CR>", "a<CRLF>b<CRLF>c<CRLF>d<CRLF>"},
+		{CR, "a<CRLF>b<LF>c<CR>d<CR>", "a<CR>b<CR>c<CR>d<CR>"},
+	} {
+		dst := make([]byte, 64)
+		nDst, _, err := NewTransformer(tc.to).Transform(dst, []byte(pre(tc.in)), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(dst[:nDst]); got != pre(tc.want) {
+			t.Errorf("all to %s\nin   %s\ngot  %q\nwant %q", tc.to, tc.in, got, pre(tc.want))
+		}
+	}
+}
+
+func TestTransformShort(t *testing.T) {
+	tr := NewTransformer(LF, CRLF)
+
+	for _, tc := range []struct {
+		dst          int
+		src          string
+		atEOF        bool
+		want         string
+		wantSrc      int
+		wantErr      error
+		name, reason string
+	}{
+		{8, "ab\r", false, "ab", 2, ErrShortSrc, "held CR", "a CR at the end might start a CRLF"},
+		{8, "ab\r", true, "ab\r", 3, nil, "CR at EOF", "a CR at EOF is bare"},
+		{8, "ab\r\n", false, "ab\n", 4, nil, "whole CRLF", ""},
+		{2, "abc", true, "ab", 2, ErrShortDst, "full dst", ""},
+	} {
+		dst := make([]byte, tc.dst)
+		nDst, nSrc, err := tr.Transform(dst, []byte(tc.src), tc.atEOF)
+		if string(dst[:nDst]) != tc.want || nSrc != tc.wantSrc || err != tc.wantErr {
+			t.Errorf("%s: Transform(%q, %t) = %q, %d, %v; want %q, %d, %v",
+				tc.name, tc.src, tc.atEOF, dst[:nDst], nSrc, err, tc.want, tc.wantSrc, tc.wantErr)
+		}
+	}
+
+	u := NewUTF16Transformer(binary.LittleEndian, LF)
+	dst := make([]byte, 8)
+	if _, nSrc, err := u.Transform(dst, []byte("a\x00b"), false); nSrc != 2 || err != ErrShortSrc {
+		t.Errorf("UTF-16 Transform of a partial unit read %d, %v; want 2, ErrShortSrc", nSrc, err)
+	}
+	if nDst, _, err := u.Transform(dst, []byte("a\x00b"), true); string(dst[:nDst]) != "a\x00b" || err != nil {
+		t.Errorf("UTF-16 Transform of a partial unit at EOF = %q, %v; want it passed through", dst[:nDst], err)
+	}
+}
+
+func TestSpan(t *testing.T) {
+	for _, tc := range []struct {
+		to      Ending
+		from    []Ending
+		src     string
+		atEOF   bool
+		want    int
+		wantErr error
+	}{
+		{LF, []Ending{CRLF}, "a<LF>b<CR>c", true, 5, nil},
+		{LF, []Ending{CRLF}, "a<LF>b<CRLF>c", true, 3, ErrEndOfSpan},
+		{LF, []Ending{CRLF}, "a<LF>b<CR>", false, 3, ErrShortSrc},
+		{LF, []Ending{CRLF}, "a<LF>b<CR>", true, 4, nil},
+		{CRLF, []Ending{LF}, "a<CRLF>b<LF>", true, 4, ErrEndOfSpan},
+		{CRLF, nil, "a<CRLF>b<CR>", true, 4, ErrEndOfSpan},
+		{CRLF, nil, "a<CRLF>b<CR>", false, 4, ErrShortSrc},
+		{CR, []Ending{CR}, "a<CR>b", true, 3, nil}, // CR to CR changes nothing
+		{LF, nil, "", false, 0, nil},
+	} {
+		for _, order := range []binary.ByteOrder{nil, binary.LittleEndian} {
+			src, want := pre(tc.src), tc.want
+			if order != nil {
+				src, want = widen(src, order), 2*want
+			}
+			n, err := newTransformer(order, tc.to, tc.from).Span([]byte(src), tc.atEOF)
+			if n != want || err != tc.wantErr {
+				t.Errorf("%v to %s, %v: Span(%s, %t) = %d, %v; want %d, %v",
+					tc.from, tc.to, order, tc.src, tc.atEOF, n, err, want, tc.wantErr)
+			}
+		}
+	}
+
+	tidy := NewTransformer(LF).Tidy(Tidy{TrimSpace: true})
+	if n, err := tidy.Span([]byte("a\n"), true); n != 0 || err != ErrEndOfSpan {
+		t.Errorf("tidying Span = %d, %v; want 0, ErrEndOfSpan", n, err)
+	}
+}
+
+// TestTransformPackage streams through the transform package's
+// Reader, a byte at a time, its Writer, and its String.
+func TestTransformPackage(t *testing.T) {
+	for _, tc := range directions {
+		for i, in := range tc.ins {
+			src, want := pre(in), pre(tc.wants[i])
+			tr := NewTransformer(tc.to, tc.from)
+
+			b, err := io.ReadAll(transform.NewReader(iotest.OneByteReader(strings.NewReader(src)), tr))
+			if err != nil || string(b) != want {
+				t.Errorf("%s to %s, Reader\nin   %s\ngot  %q, %v\nwant %q", tc.from, tc.to, in, b, err, want)
+			}
+
+			buf := &bytes.Buffer{}
+			w := transform.NewWriter(buf, tr)
+			for j := range len(src) {
+				if _, err := w.Write([]byte{src[j]}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil || buf.String() != want {
+				t.Errorf("%s to %s, Writer\nin   %s\ngot  %q, %v\nwant %q", tc.from, tc.to, in, buf, err, want)
+			}
+
+			got, n, err := transform.String(tr, src)
+			if err != nil || got != want || n != len(src) {
+				t.Errorf("%s to %s, String\nin   %s\ngot  %q, %d, %v\nwant %q, %d", tc.from, tc.to, in, got, n, err, want, len(src))
+			}
+		}
+	}
+
+	tidy := NewTransformer(LF).Tidy(Tidy{TrimSpace: true, FinalNewline: true})
+	if got, _, err := transform.String(tidy, "a  \r\nb\t"); err != nil || got != "a\nb\n" {
+		t.Errorf("tidying String = %q, %v; want %q", got, err, "a\nb\n")
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, e := range []Ending{LF, CRLF, CR} {
+		got, err := Parse(strings.ToLower(e.String()))
+		if err != nil || got != e {
+			t.Errorf("Parse(%s) = %v, %v; want %v", strings.ToLower(e.String()), got, err, e)
+		}
+		if !bytes.Equal(e.Bytes(), []byte(pre("<"+e.String()+">"))) {
+			t.Errorf("%s.Bytes() = %q", e, e.Bytes())
+		}
+	}
+	if _, err := Parse("crcr"); err == nil {
+		t.Errorf("Parse(crcr) didn't error")
+	}
+}
@@ -0,0 +1,155 @@
+package eol
+
+import "io"
+
+const bufSize = 4096
+
+// Writer converts what is written to it, and writes the
+// conversion to an underlying writer.  A CR at the end of a
+// write is held back until the next write, or Close, shows
+// whether it starts a CRLF.
+type Writer struct {
+	w    io.Writer
+	t    *Transformer
+	dst  []byte
+	held []byte // src that t could not transform yet
+}
+
+// NewWriter returns a Writer that replaces the from line
+// endings, or every line ending if from is empty, with to.
+func NewWriter(w io.Writer, to Ending, from ...Ending) *Writer {
+	return NewTransformer(to, from...).Writer(w)
+}
+
+// Writer returns a Writer that converts with t.
+func (t *Transformer) Writer(w io.Writer) *Writer {
+	return &Writer{w: w, t: t, dst: make([]byte, bufSize)}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	src := p
+	if len(w.held) > 0 {
+		w.held = append(w.held, p...)
+		src = w.held
+	}
+
+	rest, err := w.transform(src, false)
+	if err != nil {
+		return 0, err
+	}
+	w.held = append(w.held[:0], rest...)
+	return len(p), nil
+}
+
+// Close writes out anything held back.  It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	_, err := w.transform(w.held, true)
+	w.held = w.held[:0]
+	return err
+}
+
+// transform writes the conversion of as much of src as it can,
+// returning the rest.
+func (w *Writer) transform(src []byte, atEOF bool) (rest []byte, err error) {
+	for {
+		nDst, nSrc, terr := w.t.Transform(w.dst, src, atEOF)
+		if _, err := w.w.Write(w.dst[:nDst]); err != nil {
+			return nil, err
+		}
+		src = src[nSrc:]
+		if terr != ErrShortDst {
+			return src, nil
+		}
+	}
+}
+
+// Reader converts what it reads from an underlying reader.
+type Reader struct {
+	r   io.Reader
+	t   *Transformer
+	err error // from r
+
+	src        []byte
+	src0, src1 int // src[src0:src1] is read but not converted
+	dst        []byte
+	dst0, dst1 int // dst[dst0:dst1] is converted but not returned
+}
+
+// NewReader returns a Reader that replaces the from line endings,
+// or every line ending if from is empty, with to.
+func NewReader(r io.Reader, to Ending, from ...Ending) *Reader {
+	return NewTransformer(to, from...).Reader(r)
+}
+
+// Reader returns a Reader that converts with t.
+func (t *Transformer) Reader(r io.Reader) *Reader {
+	return &Reader{
+		r:   r,
+		t:   t,
+		src: make([]byte, bufSize),
+		dst: make([]byte, bufSize),
+	}
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for {
+		if r.dst0 < r.dst1 {
+			n := copy(p, r.dst[r.dst0:r.dst1])
+			r.dst0 += n
+			return n, nil
+		}
+
+		if r.src0 < r.src1 || r.err != nil {
+			atEOF := r.err != nil
+			nDst, nSrc, _ := r.t.Transform(r.dst, r.src[r.src0:r.src1], atEOF)
+			r.dst0, r.dst1 = 0, nDst
+			r.src0 += nSrc
+			if nDst > 0 {
+				continue
+			}
+			if atEOF {
+				return 0, r.err
+			}
+		}
+
+		// Transform needs more src
+		r.src1 = copy(r.src, r.src[r.src0:r.src1])
+		r.src0 = 0
+		var n int
+		n, r.err = r.r.Read(r.src[r.src1:])
+		r.src1 += n
+	}
+}
+
+// SpanReader reads r until it finds what t would change, and
+// returns how many bytes from the start of r t would leave as
+// they are.  All reports whether that is all of r, so that it
+// could be copied as is, rather than converted.
+func (t *Transformer) SpanReader(r io.Reader) (n int64, all bool, err error) {
+	var (
+		buf  = make([]byte, bufSize)
+		held int // bytes at the start of buf Span could not tell about
+	)
+	for {
+		nr, rerr := r.Read(buf[held:])
+		if rerr != nil && rerr != io.EOF {
+			return n, false, rerr
+		}
+		src, atEOF := buf[:held+nr], rerr == io.EOF
+
+		ns, err := t.Span(src, atEOF)
+		n += int64(ns)
+		switch err {
+		case nil:
+			if atEOF {
+				return n, true, nil
+			}
+			held = 0
+		case ErrShortSrc:
+			held = copy(buf, src[ns:])
+		default:
+			return n, false, nil
+		}
+	}
+}
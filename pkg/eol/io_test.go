@@ -0,0 +1,128 @@
+package eol
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+var streams = []struct {
+	to       Ending
+	from     []Ending
+	in, want string
+}{
+	{LF, []Ending{CRLF}, "a<CRLF>b<CR>c<CRLF>", "a<LF>b<CR>c<LF>"},
+	{CRLF, []Ending{LF}, "a<LF>b<CRLF>c<CR>", "a<CRLF>b<CRLF>c<CR>"},
+	{LF, nil, "<CR><CR><LF>a<CR>", "<LF><LF>a<LF>"},
+}
+
+// TestWriter writes a byte at a time, so every CR ends a write.
+func TestWriter(t *testing.T) {
+	for _, tc := range streams {
+		buf := &bytes.Buffer{}
+		w := NewWriter(buf, tc.to, tc.from...)
+		for _, b := range []byte(pre(tc.in)) {
+			if _, err := w.Write([]byte{b}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != pre(tc.want) {
+			t.Errorf("%v to %s\nin   %s\ngot  %q\nwant %q", tc.from, tc.to, tc.in, got, pre(tc.want))
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	for _, tc := range streams {
+		r := NewReader(iotest.OneByteReader(strings.NewReader(pre(tc.in))), tc.to, tc.from...)
+		b, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(b); got != pre(tc.want) {
+			t.Errorf("%v to %s\nin   %s\ngot  %q\nwant %q", tc.from, tc.to, tc.in, got, pre(tc.want))
+		}
+	}
+
+	big := strings.Repeat("abc\r\n", 3*bufSize)
+	if err := iotest.TestReader(NewReader(strings.NewReader(big), LF, CRLF), []byte(strings.ReplaceAll(big, "\r\n", "\n"))); err != nil {
+		t.Error(err)
+	}
+}
+
+// FuzzReaderWriter checks that a Reader and a Writer agree with
+// Transform done in one go, for each conversion.
+func FuzzReaderWriter(f *testing.F) {
+	for _, in := range mixed {
+		f.Add(pre(in))
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		for _, to := range []Ending{LF, CRLF, CR} {
+			tr := NewTransformer(to)
+			dst := make([]byte, 2*len(in))
+			nDst, _, err := tr.Transform(dst, []byte(in), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := string(dst[:nDst])
+
+			b, err := io.ReadAll(tr.Reader(iotest.HalfReader(strings.NewReader(in))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != want {
+				t.Errorf("Reader to %s\nin   %q\ngot  %q\nwant %q", to, in, b, want)
+			}
+
+			buf := &bytes.Buffer{}
+			w := tr.Writer(buf)
+			io.Copy(w, iotest.OneByteReader(strings.NewReader(in)))
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != want {
+				t.Errorf("Writer to %s\nin   %q\ngot  %q\nwant %q", to, in, buf, want)
+			}
+		}
+	})
+}
+
+// FuzzSpanReader checks that Transform leaves alone what Span
+// says it will, and changes something when Span says it will,
+// and that SpanReader agrees with Span done in one go.
+func FuzzSpanReader(f *testing.F) {
+	for _, in := range mixed {
+		f.Add(pre(in))
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		for _, to := range []Ending{LF, CRLF, CR} {
+			for _, from := range [][]Ending{nil, {LF}, {CRLF}, {CR}} {
+				tr := NewTransformer(to, from...)
+				dst := make([]byte, 2*len(in))
+				nDst, _, err := tr.Transform(dst, []byte(in), true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out := string(dst[:nDst])
+
+				n, err := tr.Span([]byte(in), true)
+				if !strings.HasPrefix(out, in[:n]) {
+					t.Errorf("%v to %s: Transform(%q) = %q changes the first %d bytes Span passed", from, to, in, out, n)
+				}
+				if (err == nil) != (out == in) {
+					t.Errorf("%v to %s: Span(%q) = %d, %v, but Transform gives %q", from, to, in, n, err, out)
+				}
+
+				nr, all, rerr := tr.SpanReader(iotest.OneByteReader(strings.NewReader(in)))
+				if rerr != nil || nr != int64(n) || all != (err == nil) {
+					t.Errorf("%v to %s: SpanReader(%q) = %d, %t, %v; want %d, %t", from, to, in, nr, all, rerr, n, err == nil)
+				}
+			}
+		}
+	})
+}
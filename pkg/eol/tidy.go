@@ -0,0 +1,146 @@
+package eol
+
+// Tidy says how a Transformer fixes up the ends of lines and of
+// the text, in the same pass as it replaces line endings.
+type Tidy struct {
+	// TrimSpace strips spaces and tabs from the end of each
+	// line.  A line of only spaces and tabs becomes blank.
+	TrimSpace bool
+
+	// FinalNewline ends the text with the to line ending, if
+	// its last line has none.  Empty text is left empty.
+	FinalNewline bool
+
+	// SqueezeBlank collapses the blank lines at the end of the
+	// text into one.
+	SqueezeBlank bool
+}
+
+// tidyState is what a tidying Transformer holds between calls to
+// Transform.
+type tidyState struct {
+	out    []byte // decided, but not yet copied to dst
+	spaces []byte // trailing spaces and tabs on this line so far
+	blanks []byte // the endings of blank lines, not yet known to be trailing
+	first  Ending // the ending of the first of blanks
+
+	text bool // this line has text
+	done bool // the end of the text is tidied
+}
+
+// Tidy returns a copy of t that also tidies as opts says.  Unlike
+// t, the copy keeps state, so give each text its own, or Reset it
+// between texts.
+func (t *Transformer) Tidy(opts Tidy) *Transformer {
+	u := *t
+	u.tidy = opts
+	u.state = tidyState{}
+	return &u
+}
+
+// transformTidy is Transform for a Transformer that tidies.
+// Spaces and tabs at the end of a line, and blank lines at the end
+// of the text, are held until what comes after shows whether to
+// keep them, however many calls to Transform that takes.
+func (t *Transformer) transformTidy(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	st := &t.state
+	w := t.width()
+	for {
+		if nDst = t.drain(dst, nDst); len(st.out) > 0 {
+			return nDst, nSrc, ErrShortDst
+		}
+		if nSrc+w > len(src) {
+			break
+		}
+
+		c := t.unit(src[nSrc:])
+		next := nSrc + w
+		switch {
+		case c == lf:
+			t.end(LF)
+		case c == cr && next+w <= len(src) && t.unit(src[next:]) == lf:
+			t.end(CRLF)
+			next += w
+		case c == cr && next+w > len(src) && !atEOF:
+			return nDst, nSrc, ErrShortSrc
+		case c == cr:
+			t.end(CR)
+		case (c == ' ' || c == '\t') && t.tidy.TrimSpace:
+			st.spaces = append(st.spaces, src[nSrc:next]...)
+		default:
+			t.text(src[nSrc:next])
+		}
+		nSrc = next
+	}
+
+	if !atEOF {
+		if nSrc < len(src) {
+			return nDst, nSrc, ErrShortSrc
+		}
+		return nDst, nSrc, nil
+	}
+	if !st.done {
+		st.done = true
+		t.finish()
+		// a partial code unit is passed through, after the tidying
+		st.out = append(st.out, src[nSrc:]...)
+		nSrc = len(src)
+	}
+	if nDst = t.drain(dst, nDst); len(st.out) > 0 {
+		return nDst, nSrc, ErrShortDst
+	}
+	return nDst, nSrc, nil
+}
+
+// drain copies as much of the held output as fits into dst[nDst:].
+func (t *Transformer) drain(dst []byte, nDst int) int {
+	st := &t.state
+	n := copy(dst[nDst:], st.out)
+	st.out = append(st.out[:0], st.out[n:]...)
+	return nDst + n
+}
+
+// text takes a code unit of text, encoded as raw.  What was held
+// is now known not to end the line, or the text, so it goes out
+// first.
+func (t *Transformer) text(raw []byte) {
+	st := &t.state
+	st.out = append(st.out, st.blanks...)
+	st.out = append(st.out, st.spaces...)
+	st.out = append(st.out, raw...)
+	st.blanks, st.spaces = st.blanks[:0], st.spaces[:0]
+	st.text = true
+}
+
+// end takes the line ending e, replacing it if it's one of t's.
+func (t *Transformer) end(e Ending) {
+	st := &t.state
+	if t.from[e] {
+		e = t.to
+	}
+	st.spaces = st.spaces[:0]
+
+	switch {
+	case !st.text && t.tidy.SqueezeBlank:
+		if len(st.blanks) == 0 {
+			st.first = e
+		}
+		st.blanks = append(st.blanks, t.seqs[e]...)
+	default:
+		st.out = append(st.out, t.seqs[e]...)
+	}
+	st.text = false
+}
+
+// finish tidies the end of the text.  Held spaces and tabs are
+// dropped, and held blank lines collapse into one.
+func (t *Transformer) finish() {
+	st := &t.state
+	if len(st.blanks) > 0 {
+		st.out = append(st.out, t.seqs[st.first]...)
+	}
+	if st.text && t.tidy.FinalNewline {
+		st.out = append(st.out, t.seqs[t.to]...)
+	}
+	st.blanks, st.spaces = nil, nil
+}
@@ -0,0 +1,78 @@
+package eol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestTidy(t *testing.T) {
+	all := Tidy{TrimSpace: true, FinalNewline: true, SqueezeBlank: true}
+	for _, tc := range []struct {
+		tidy     Tidy
+		to       Ending
+		in, want string
+	}{
+		{Tidy{TrimSpace: true}, LF, "a \t<CRLF>b<CRLF> c  ", "a<LF>b<LF> c"},
+		{Tidy{TrimSpace: true}, LF, "a<LF>  <LF>b", "a<LF><LF>b"},
+		{Tidy{FinalNewline: true}, CRLF, "a<LF>b", "a<CRLF>b<CRLF>"},
+		{Tidy{FinalNewline: true}, CRLF, "a<LF>", "a<CRLF>"},
+		{Tidy{FinalNewline: true}, CRLF, "", ""},
+		{Tidy{SqueezeBlank: true}, LF, "a<LF><LF><CRLF><LF>", "a<LF><LF>"},
+		{Tidy{SqueezeBlank: true}, LF, "<LF><LF>a<LF><LF>b", "<LF><LF>a<LF><LF>b"},
+		{Tidy{SqueezeBlank: true}, LF, "a<LF>  <LF><LF>", "a<LF>  <LF><LF>"},
+		{all, LF, "a  <LF> <LF>\t<LF><LF>", "a<LF><LF>"},
+		{all, CRLF, "a<LF><LF>b  ", "a<CRLF><CRLF>b<CRLF>"},
+		{all, LF, "  <LF><LF>", "<LF>"},
+	} {
+		for _, order := range []binary.ByteOrder{nil, binary.BigEndian} {
+			src, want := pre(tc.in), pre(tc.want)
+			if order != nil {
+				src, want = widen(src, order), widen(want, order)
+			}
+
+			// a byte at a time, so everything held is held across writes
+			buf := &bytes.Buffer{}
+			w := newTransformer(order, tc.to, nil).Tidy(tc.tidy).Writer(buf)
+			for i := range len(src) {
+				if _, err := w.Write([]byte{src[i]}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != want {
+				t.Errorf("%+v to %s, %v\nin   %s\ngot  %q\nwant %q", tc.tidy, tc.to, order, tc.in, got, want)
+			}
+		}
+	}
+}
+
+// TestTidyShortDst drains held output through a dst too small for
+// it, across calls.
+func TestTidyShortDst(t *testing.T) {
+	tr := NewTransformer(CRLF).Tidy(Tidy{FinalNewline: true})
+	var got []byte
+	src := []byte("ab\ncd")
+	dst := make([]byte, 1)
+	for {
+		nDst, nSrc, err := tr.Transform(dst, src, true)
+		got = append(got, dst[:nDst]...)
+		src = src[nSrc:]
+		if err == nil {
+			break
+		}
+		if err != ErrShortDst {
+			t.Fatal(err)
+		}
+	}
+	if want := "ab\r\ncd\r\n"; string(got) != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+
+	tr.Reset()
+	if nDst, _, _ := tr.Transform(dst, []byte("x"), true); string(dst[:nDst]) != "x" {
+		t.Errorf("after Reset, got %q; want x", dst[:nDst])
+	}
+}
@@ -0,0 +1,223 @@
+package eolcmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"zacharysyoung/CLUtils/pkg/cli"
+	"zacharysyoung/CLUtils/pkg/eol"
+	"zacharysyoung/CLUtils/pkg/textfile"
+)
+
+// printInfo prints the Info of each file, or of stdin if there
+// are none.  It returns false if any file could not be read.
+func printInfo(args []string) bool {
+	infos, ok := scanAll(args)
+
+	write := textfile.WriteInfoTable
+	if jsonFlag {
+		write = textfile.WriteInfoJSON
+	}
+	if err := write(os.Stdout, infos); err != nil {
+		cli.ErrorOut(err.Error())
+	}
+	return ok
+}
+
+// checkFiles prints each file, or stdin if there are none, that
+// converting would change, with the first line it would change.
+// It returns false if any would be changed or could not be read.
+func checkFiles(args []string) bool {
+	infos, ok := scanAll(args)
+	for _, info := range infos {
+		if info.Binary && !force {
+			cli.Warn(fmt.Sprintf("skipping %s: %v", info.Name, textfile.ErrBinary))
+			continue
+		}
+		var from []eol.Ending
+		if len(args) > 0 {
+			f, _, skip, err := endingsFor(info.Name)
+			if err != nil {
+				cli.Error(err.Error())
+				ok = false
+				continue
+			}
+			if skip {
+				continue
+			}
+			from = f
+		}
+		if line, why := offense(info, from...); line > 0 {
+			fmt.Printf("%s:%d: %s\n", info.Name, line, why)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// diffFiles prints a diff of converting each file, or stdin if
+// there are none.  It returns false if any file could not be read.
+func diffFiles(args []string) bool {
+	if len(args) == 0 {
+		if err := diffStdin(); err != nil {
+			cli.ErrorOut(err.Error())
+		}
+		return true
+	}
+
+	paths, sum := args, textfile.Summary{}
+	if recursive {
+		paths, sum = walk(args)
+	}
+	ok := sum.Failed == 0
+	for _, path := range paths {
+		from, to, skip, err := endingsFor(path)
+		if skip {
+			continue
+		}
+		if err == nil {
+			err = diffFile(path, path, to, from...)
+		}
+		switch {
+		case errors.Is(err, textfile.ErrBinary), errors.Is(err, textfile.ErrUnsupported):
+			cli.Warn(fmt.Sprintf("skipping %v", err))
+		case err != nil:
+			cli.Error(err.Error())
+			ok = false
+		}
+	}
+	return ok
+}
+
+// diffStdin prints a diff of converting stdin, which is copied to
+// a temp file, so it can be read twice.
+func diffStdin() error {
+	f, err := os.CreateTemp("", cli.Name)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, os.Stdin)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	from, to := endings()
+	return diffFile(f.Name(), "stdin", to, from)
+}
+
+// diffFile prints a diff, under name, of the file at path and its
+// conversion, decompressed if need be.  The two are read side by
+// side, a line at a time.
+func diffFile(path, name string, to eol.Ending, from ...eol.Ending) error {
+	old, err := textfile.OpenText(path)
+	if err != nil {
+		return err
+	}
+	defer old.Close()
+	in, err := textfile.OpenText(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() { pw.CloseWithError(convert(in, pw, to, from...)) }()
+
+	if _, err := textfile.Diff(os.Stdout, name, old, pr); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// offense returns the first line that converting the from line
+// endings, or -from if none, would change in info's file, and
+// why, or 0 if converting changes nothing.
+func offense(info textfile.Info, from ...eol.Ending) (line int, why string) {
+	switch {
+	case removeBOM && info.BOM:
+		return 1, "byte order mark"
+	case addBOM && !info.BOM:
+		return 1, "no byte order mark"
+	}
+
+	// note keeps the earliest line
+	note := func(l int, w string) {
+		if l > 0 && (line == 0 || l < line) {
+			line, why = l, w
+		}
+	}
+
+	if len(from) == 0 {
+		f, _ := endings()
+		from = []eol.Ending{f}
+	}
+	for _, e := range from {
+		if n, first := found(info, e); n > 0 {
+			note(first, e.String()+" line ending")
+		}
+	}
+	if trimSpace {
+		note(info.FirstTrailingSpace, "trailing whitespace")
+	}
+	if finalNewline && !info.FinalNewline {
+		note(info.Lines, "no final newline")
+	}
+	if squeezeBlank && info.TrailingBlank > 1 {
+		note(info.Lines-info.TrailingBlank+2, "trailing blank lines")
+	}
+	return line, why
+}
+
+// found returns how many of the line ending e info counted, and
+// the first line to end with one.
+func found(info textfile.Info, e eol.Ending) (n, first int) {
+	switch e {
+	case eol.CRLF:
+		return info.CRLF, info.FirstCRLF
+	case eol.CR:
+		return info.CR, info.FirstCR
+	}
+	return info.LF, info.FirstLF
+}
+
+// scanAll returns the Info of each file, or of stdin if there are
+// none, skipping files compressed in a way it can't read.  It
+// returns false if any file could not be read.
+func scanAll(args []string) ([]textfile.Info, bool) {
+	paths, sum := args, textfile.Summary{}
+	if recursive {
+		paths, sum = walk(args)
+	}
+	ok := sum.Failed == 0
+
+	var infos []textfile.Info
+	if len(args) == 0 {
+		info, err := textfile.Scan(os.Stdin)
+		if err != nil {
+			cli.ErrorOut(err.Error())
+		}
+		info.Name = "stdin"
+		infos = append(infos, info)
+	}
+	for _, path := range paths {
+		info, err := textfile.ScanFile(path)
+		switch {
+		case errors.Is(err, textfile.ErrBinary), errors.Is(err, textfile.ErrUnsupported):
+			cli.Warn(fmt.Sprintf("skipping %v", err))
+			continue
+		case err != nil:
+			cli.Error(err.Error())
+			ok = false
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, ok
+}
@@ -0,0 +1,81 @@
+package eolcmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"zacharysyoung/CLUtils/pkg/textfile"
+)
+
+func Test_offense(t *testing.T) {
+	for _, tc := range []struct {
+		cmd  Command
+		info textfile.Info
+		want int
+	}{
+		{Dos2unix, textfile.Info{}, 0},
+		{Dos2unix, textfile.Info{LF: 3, FirstLF: 1, CR: 1, FirstCR: 2}, 0},
+		{Dos2unix, textfile.Info{LF: 3, FirstLF: 1, CRLF: 2, FirstCRLF: 4}, 4},
+		{Unix2dos, textfile.Info{}, 0},
+		{Unix2dos, textfile.Info{CRLF: 3, FirstCRLF: 1, CR: 1, FirstCR: 2}, 0},
+		{Unix2dos, textfile.Info{CRLF: 3, FirstCRLF: 1, LF: 2, FirstLF: 4}, 4},
+	} {
+		use(t, tc.cmd)
+		if got, _ := offense(tc.info); got != tc.want {
+			t.Errorf("%s: offense(%+v) = %d; want %d", tc.cmd.Name, tc.info, got, tc.want)
+		}
+	}
+}
+
+// Test_offenseTidy checks that -check reports what tidying would
+// change, at the earliest line.
+func Test_offenseTidy(t *testing.T) {
+	trimSpace, finalNewline, squeezeBlank = true, true, true
+	defer func() { trimSpace, finalNewline, squeezeBlank = false, false, false }()
+
+	for _, tc := range []struct {
+		cmd  Command
+		in   string
+		want int
+	}{
+		{Dos2unix, "a\nb\n", 0},
+		{Dos2unix, "a\nb  \nc", 2},
+		{Dos2unix, "a\nb", 2},
+		{Dos2unix, "a\n\n\n\n", 3},
+		{Dos2unix, "", 0},
+		{Unix2dos, "a\r\nb\r\n", 0},
+		{Unix2dos, "a\r\nb  \r\nc", 2},
+		{Unix2dos, "a\r\nb", 2},
+		{Unix2dos, "a\r\n\r\n\r\n\r\n", 3},
+		{Unix2dos, "", 0},
+	} {
+		use(t, tc.cmd)
+		info, err := textfile.Scan(strings.NewReader(tc.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, why := offense(info); got != tc.want {
+			t.Errorf("%s: offense of %q = %d (%s); want %d", tc.cmd.Name, tc.in, got, why, tc.want)
+		}
+	}
+}
+
+// Test_scanAll checks that a zstd file, which can't be read, is
+// skipped and not counted as a failure.
+func Test_scanAll(t *testing.T) {
+	dir := t.TempDir()
+	zst, txt := filepath.Join(dir, "a.zst"), filepath.Join(dir, "b.txt")
+	if err := os.WriteFile(zst, []byte("\x28\xb5\x2f\xfd\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(txt, []byte("a\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, ok := scanAll([]string{zst, txt})
+	if !ok || len(infos) != 1 || infos[0].Name != txt {
+		t.Errorf("scanAll = %+v, %t; want only %s, true", infos, ok, txt)
+	}
+}
@@ -0,0 +1,299 @@
+package eolcmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"zacharysyoung/CLUtils/pkg/eol"
+	"zacharysyoung/CLUtils/pkg/textfile"
+)
+
+// run converts in to out, from -from to -to, refusing binary
+// input unless -force.
+func run(in io.Reader, out io.Writer) error {
+	from, to := endings()
+	return convert(in, out, to, from)
+}
+
+// withCompression converts in, named name, to out with conv,
+// decompressing in first if it is compressed.  If recompress, out
+// is compressed the same way.
+func withCompression(name string, in io.Reader, out io.Writer, recompress bool, conv textfile.Converter) error {
+	c, in, err := textfile.DetectCompression(name, in)
+	if err != nil {
+		return err
+	}
+	if c == textfile.Uncompressed {
+		return conv(in, out)
+	}
+
+	zr, err := c.Reader(in)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	if !recompress {
+		return conv(zr, out)
+	}
+
+	zw, err := c.Writer(out)
+	if err != nil {
+		return err
+	}
+	if err := conv(zr, zw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// convert is run, replacing the from line endings, or every line
+// ending if from is empty, with to.  If in is a regular file, the
+// start of it that needs no converting is passed through; see
+// passThrough.
+func convert(in io.Reader, out io.Writer, to eol.Ending, from ...eol.Ending) error {
+	f, offset := regularFile(in)
+
+	enc, in, err := textfile.ReadBOM(in)
+	if err != nil {
+		return err
+	}
+	if !force {
+		if in, err = textfile.CheckText(in, enc); err != nil {
+			return err
+		}
+	}
+
+	if _, err := out.Write(bomFor(enc)); err != nil {
+		return err
+	}
+	if f != nil {
+		start := offset + int64(len(enc.BOM()))
+		if in, err = passThrough(f, start, in, out, transformer(enc, to, from...)); err != nil {
+			return err
+		}
+	}
+	if order := enc.ByteOrder(); order != nil {
+		return replaceUTF16(in, out, order, to, from...)
+	}
+	return _replaceBytes(in, out, defaultBufSize, to, from...)
+}
+
+// unchanged reports whether convert would leave what it reads
+// from in as it is, and writes nothing.  It stops reading at the
+// first thing convert would change.  Without tidying, that is
+// found by a Span; tidying a line can depend on the lines after
+// it, so then in is converted, and compared as it goes with what
+// was read.
+func unchanged(in io.Reader, to eol.Ending, from ...eol.Ending) (bool, error) {
+	if tidy() != (eol.Tidy{}) {
+		w := &sameWriter{}
+		err := convert(io.TeeReader(in, &w.read), w, to, from...)
+		if errors.Is(err, errChanged) {
+			return false, nil
+		}
+		return err == nil && w.read.Len() == 0, err
+	}
+
+	enc, in, err := textfile.ReadBOM(in)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(bomFor(enc), enc.BOM()) {
+		return false, nil
+	}
+	if !force {
+		if in, err = textfile.CheckText(in, enc); err != nil {
+			return false, err
+		}
+	}
+	_, all, err := transformer(enc, to, from...).SpanReader(in)
+	return all, err
+}
+
+// errChanged stops convert at the first thing it changes.
+var errChanged = errors.New("changed")
+
+// sameWriter checks that what is written to it is what was read,
+// which is teed into read.  Since convert never writes ahead of
+// what it has read, read only holds what it hasn't written yet.
+type sameWriter struct{ read bytes.Buffer }
+
+func (w *sameWriter) Write(p []byte) (int, error) {
+	if !bytes.HasPrefix(w.read.Bytes(), p) {
+		return 0, errChanged
+	}
+	w.read.Next(len(p))
+	return len(p), nil
+}
+
+// regularFile returns in as a regular file, and where it is
+// reading from, or nil if in is something else.
+func regularFile(in io.Reader) (f *os.File, offset int64) {
+	f, ok := in.(*os.File)
+	if !ok {
+		return nil, 0
+	}
+	if fi, err := f.Stat(); err != nil || !fi.Mode().IsRegular() {
+		return nil, 0
+	}
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0
+	}
+	return f, offset
+}
+
+// passThrough copies what t would leave as it is, from start in f
+// up to the first thing t would change, to out with io.Copy.  That
+// lets the kernel move the bytes itself, with copy_file_range,
+// sendfile or splice on Linux, rather than read them into and
+// write them out of a buffer.  In is f, read from start, though
+// maybe read ahead of it.  The returned reader is the rest of f,
+// to be converted.
+func passThrough(f *os.File, start int64, in io.Reader, out io.Writer, t *eol.Transformer) (io.Reader, error) {
+	n, _, err := t.SpanReader(in)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(out, f, n); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// bomFor returns the BOM to write ahead of input read as enc.
+func bomFor(enc textfile.Encoding) []byte {
+	switch {
+	case removeBOM:
+		return nil
+	case addBOM && enc == textfile.Plain:
+		return textfile.UTF8.BOM()
+	}
+	return enc.BOM()
+}
+
+// -- by bytes --
+
+// endings returns the -from and -to line endings.
+func endings() (from, to eol.Ending) {
+	from, _ = eol.Parse(fromFlag)
+	to, _ = eol.Parse(toFlag)
+	return from, to
+}
+
+// rules finds each file's config, with -config.
+var rules = textfile.NewRules()
+
+// endingsFor returns the line endings for the file at path: from
+// -from to -to, or, with -config, to the line ending its config
+// gives, from every other.  Skip is true if its config marks it
+// as not text.
+func endingsFor(path string) (from []eol.Ending, to eol.Ending, skip bool, err error) {
+	f, to := endings()
+	if !configFlag {
+		return []eol.Ending{f}, to, false, nil
+	}
+
+	rule, err := rules.For(path)
+	switch {
+	case err != nil:
+		return nil, 0, false, err
+	case rule.Skip:
+		return nil, 0, true, nil
+	case rule.EOL == "":
+		return []eol.Ending{f}, to, false, nil
+	}
+	to, err = eol.Parse(rule.EOL)
+	for _, e := range []eol.Ending{eol.LF, eol.CRLF, eol.CR} {
+		if e != to {
+			from = append(from, e)
+		}
+	}
+	return from, to, false, err
+}
+
+// defaultBufSize is past the break even point: smaller and it
+// runs slower; bigger and it doesn't run any faster.  See
+// benchmarks.txt.
+const defaultBufSize = 8192
+
+func replaceBytes(in io.Reader, out io.Writer) error {
+	from, to := endings()
+	return _replaceBytes(in, out, defaultBufSize, to, from)
+}
+
+// _replaceBytes replaces the from line endings in in, or every
+// line ending if from is empty, with to, tidying as the flags
+// say, reading size bytes at a time.  Each read is converted and
+// written out before the next, so endless inputs (tail -f) come
+// out as they go in.
+func _replaceBytes(in io.Reader, out io.Writer, size int, to eol.Ending, from ...eol.Ending) error {
+	return copyThrough(tidied(eol.NewTransformer(to, from...)).Writer(out), in, size)
+}
+
+// replaceBytesSize allows for benchmarking various sized
+// buffers.
+func replaceBytesSize(in io.Reader, out io.Writer, size int) error {
+	from, to := endings()
+	return _replaceBytes(in, out, size, to, from)
+}
+
+// -- by UTF-16 code unit --
+
+// replaceUTF16 is _replaceBytes for UTF-16 text, where line endings
+// are made of 2-byte code units, in order, and not of bytes.  A
+// trailing odd byte is passed through.
+func replaceUTF16(in io.Reader, out io.Writer, order binary.ByteOrder, to eol.Ending, from ...eol.Ending) error {
+	return copyThrough(tidied(eol.NewUTF16Transformer(order, to, from...)).Writer(out), in, defaultBufSize)
+}
+
+// transformer returns the Transformer for text in enc, tidying
+// as the flags say.
+func transformer(enc textfile.Encoding, to eol.Ending, from ...eol.Ending) *eol.Transformer {
+	if order := enc.ByteOrder(); order != nil {
+		return tidied(eol.NewUTF16Transformer(order, to, from...))
+	}
+	return tidied(eol.NewTransformer(to, from...))
+}
+
+// tidy returns the Tidy options -trim-space, -final-newline and
+// -squeeze-blank set.
+func tidy() eol.Tidy {
+	return eol.Tidy{TrimSpace: trimSpace, FinalNewline: finalNewline, SqueezeBlank: squeezeBlank}
+}
+
+// tidied returns t, tidying as -trim-space, -final-newline and
+// -squeeze-blank say.
+func tidied(t *eol.Transformer) *eol.Transformer {
+	opts := tidy()
+	if opts == (eol.Tidy{}) {
+		return t
+	}
+	return t.Tidy(opts)
+}
+
+// copyThrough writes in to w, size bytes at a time, then closes w.
+func copyThrough(w *eol.Writer, in io.Reader, size int) error {
+	buf := make([]byte, size)
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
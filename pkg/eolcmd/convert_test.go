@@ -0,0 +1,734 @@
+package eolcmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"zacharysyoung/CLUtils/pkg/eol"
+	"zacharysyoung/CLUtils/pkg/textfile"
+)
+
+var (
+	x = strings.ReplaceAll
+
+	pre  = func(s string) string { return x(x(x(s, "<CRLF>", "\r\n"), "<LF>", "\n"), "<CR>", "\r") }
+	post = func(s string) string { return x(x(x(s, "\r\n", "<CRLF>"), "\n", "<LF>"), "\r", "<CR>") }
+)
+
+type testCase struct {
+	in, want string
+}
+
+var dos2unixCases = []testCase{
+	{
+		"foo<CRLF>bar",
+		"foo<LF>bar",
+	},
+	{
+		"foo<LF>bar<CRLF>",
+		"foo<LF>bar<LF>",
+	},
+	{
+		"foo<CR>bar<CRLF>",
+		"foo<CR>bar<LF>",
+	},
+	{
+		"foo<CR><CRLF>bar<CR>",
+		"foo<CRLF>bar<CR>", // a single pass leaves the first CR
+	},
+	{
+		"<CRLF><CRLF><CR>",
+		"<LF><LF><CR>",
+	},
+	{
+		"foo<LF>bar<CR>",
+		"foo<LF>bar<CR>",
+	},
+}
+
+var unix2dosCases = []testCase{
+	{
+		"foo<LF>bar",
+		"foo<CRLF>bar",
+	},
+	{
+		"foo<LF>bar<LF>",
+		"foo<CRLF>bar<CRLF>",
+	},
+	{
+		"foo<CRLF>bar<LF>",
+		"foo<CRLF>bar<CRLF>",
+	},
+	{
+		"foo<CR>bar<LF>",
+		"foo<CR>bar<CRLF>",
+	},
+	{
+		"foo<CR>bar<CRLF>",
+		"foo<CR>bar<CRLF>",
+	},
+}
+
+// commands pairs each Command with its test cases, and with
+// replaceStrings, the command done the obvious way, all in
+// memory: the reference replaceBytes is tested against.
+var commands = []struct {
+	cmd            Command
+	testCases      []testCase
+	replaceStrings func(io.Reader, io.Writer) error
+}{
+	{Dos2unix, dos2unixCases, dos2unixStrings},
+	{Unix2dos, unix2dosCases, unix2dosStrings},
+}
+
+// forEach runs f as a subtest for each of commands, with -from and
+// -to set as the command sets them.
+func forEach(t *testing.T, f func(t *testing.T, testCases []testCase)) {
+	for _, c := range commands {
+		t.Run(c.cmd.Name, func(t *testing.T) {
+			use(t, c.cmd)
+			f(t, c.testCases)
+		})
+	}
+}
+
+// use sets -from and -to to c's defaults until t is done.
+func use(t testing.TB, c Command) {
+	from, to := fromFlag, toFlag
+	fromFlag, toFlag = c.From, c.To
+	t.Cleanup(func() { fromFlag, toFlag = from, to })
+}
+
+func dos2unixStrings(in io.Reader, out io.Writer) error {
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(
+		out,
+		strings.ReplaceAll(string(b), "\r\n", "\n"))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func unix2dosStrings(in io.Reader, out io.Writer) error {
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	s := string(b)
+
+	const (
+		crlf = "\r\n"
+		lf   = "\n"
+	)
+	crlfSegments := strings.Split(s, crlf)
+	for i := range crlfSegments {
+		crlfSegments[i] = strings.ReplaceAll(crlfSegments[i], lf, crlf)
+	}
+
+	_, err = io.WriteString(out, strings.Join(crlfSegments, crlf))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Test_replace(t *testing.T) {
+	for _, c := range commands {
+		use(t, c.cmd)
+		for _, runner := range []struct {
+			f    func(io.Reader, io.Writer) error
+			name string
+		}{
+			{replaceBytes, "replaceBytes"},
+			{c.replaceStrings, "replaceStrings"},
+		} {
+			t.Run(c.cmd.Name+"/"+runner.name, func(t *testing.T) {
+				for _, tc := range c.testCases {
+					buf := &bytes.Buffer{}
+					err := runner.f(strings.NewReader(pre(tc.in)), buf)
+					if err != nil {
+						t.Fatalf("got non-nil err: %v", err)
+					}
+
+					if got := post(buf.String()); got != tc.want {
+						t.Errorf("\nin   %s\ngot  %s\nwant %s", tc.in, got, tc.want)
+					}
+				}
+			})
+		}
+	}
+}
+
+// oneByteReader returns a single byte per Read, defeating any
+// buffering in front of it.
+type oneByteReader struct{ r io.Reader }
+
+func (o *oneByteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return o.r.Read(p[:1])
+}
+
+// split is input for _replaceBytes, and the size of its reads.
+type split struct {
+	name string
+	in   io.Reader
+	size int
+}
+
+// splits returns in read size bytes at a time, for each of sizes,
+// and once more a byte at a time, whatever the size.
+func splits(in string, sizes []int) []split {
+	var s []split
+	for _, size := range sizes {
+		s = append(s, split{fmt.Sprintf("size %d", size), strings.NewReader(in), size})
+	}
+	return append(s, split{"a byte at a time", &oneByteReader{strings.NewReader(in)}, defaultBufSize})
+}
+
+// Test_replaceBytesSplit uses reads too small to hold a CRLF, so
+// CRs land at the end of one read and their LFs at the start of
+// the next.
+func Test_replaceBytesSplit(t *testing.T) {
+	forEach(t, func(t *testing.T, testCases []testCase) {
+		for _, tc := range testCases {
+			for _, sp := range splits(pre(tc.in), []int{1, 2, 3}) {
+				buf := &bytes.Buffer{}
+				err := replaceBytesSize(sp.in, buf, sp.size)
+				if err != nil {
+					t.Fatalf("got non-nil err: %v", err)
+				}
+
+				if got := post(buf.String()); got != tc.want {
+					t.Errorf("%s\nin   %s\ngot  %s\nwant %s", sp.name, tc.in, got, tc.want)
+				}
+			}
+		}
+	})
+}
+
+// Test_replaceUTF16 runs the test cases with each byte widened to
+// a 2-byte code unit.
+func Test_replaceUTF16(t *testing.T) {
+	forEach(t, func(t *testing.T, testCases []testCase) {
+		from, to := endings()
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			for _, tc := range testCases {
+				buf := &bytes.Buffer{}
+				err := replaceUTF16(strings.NewReader(widen(pre(tc.in), order)), buf, order, to, from)
+				if err != nil {
+					t.Fatalf("got non-nil err: %v", err)
+				}
+
+				if got, want := buf.String(), widen(pre(tc.want), order); got != want {
+					t.Errorf("%s\nin   %s\ngot  %q\nwant %q", order, tc.in, got, want)
+				}
+			}
+		}
+	})
+}
+
+// Test_convertFile runs the test cases from regular files, read
+// from partway in and with a BOM, so the start that needs no
+// converting is passed through by passThrough.
+func Test_convertFile(t *testing.T) {
+	forEach(t, func(t *testing.T, testCases []testCase) {
+		from, to := endings()
+		const skip = "skipped<CRLF><LF>"
+		for _, bom := range []string{"", "\xef\xbb\xbf"} {
+			for _, tc := range testCases {
+				path := path.Join(t.TempDir(), "in.txt")
+				if err := os.WriteFile(path, []byte(pre(skip+bom+tc.in)), 0644); err != nil {
+					t.Fatal(err)
+				}
+				f, err := os.Open(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := f.Seek(int64(len(pre(skip))), io.SeekStart); err != nil {
+					t.Fatal(err)
+				}
+
+				buf := &bytes.Buffer{}
+				err = convert(f, buf, to, from)
+				f.Close()
+				if err != nil {
+					t.Fatalf("got non-nil err: %v", err)
+				}
+				if got := post(buf.String()); got != bom+tc.want {
+					t.Errorf("\nin   %q\ngot  %q\nwant %q", bom+tc.in, got, bom+tc.want)
+				}
+
+				same, err := unchanged(strings.NewReader(pre(bom+tc.in)), to, from)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := tc.in == tc.want; same != want {
+					t.Errorf("unchanged(%q) = %t; want %t", bom+tc.in, same, want)
+				}
+			}
+		}
+	})
+}
+
+// Test_withCompression runs the test cases gzipped, with and
+// without recompressing the output.
+func Test_withCompression(t *testing.T) {
+	forEach(t, func(t *testing.T, testCases []testCase) {
+		for _, recompress := range []bool{false, true} {
+			for _, tc := range testCases {
+				in := &bytes.Buffer{}
+				zw, _ := textfile.Gzip.Writer(in)
+				io.WriteString(zw, pre(tc.in))
+				zw.Close()
+
+				buf := &bytes.Buffer{}
+				if err := withCompression("in.gz", in, buf, recompress, run); err != nil {
+					t.Fatalf("got non-nil err: %v", err)
+				}
+				out := io.Reader(buf)
+				if recompress {
+					zr, err := textfile.Gzip.Reader(buf)
+					if err != nil {
+						t.Fatal(err)
+					}
+					out = zr
+				}
+				b, err := io.ReadAll(out)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := post(string(b)); got != tc.want {
+					t.Errorf("recompress=%t\nin   %s\ngot  %s\nwant %s", recompress, tc.in, got, tc.want)
+				}
+			}
+		}
+	})
+}
+
+// Test_convertFileTidy converts files, gzipped and not, with a
+// tidy flag: a file tidying leaves as it is is not rewritten, even
+// though gzipping it again would give other bytes, and gets no
+// backup.
+func Test_convertFileTidy(t *testing.T) {
+	use(t, Dos2unix)
+	trimSpace, backupFlag = true, ".bak"
+	defer func() { trimSpace, backupFlag = false, "" }()
+
+	for _, tc := range []struct {
+		name, in, want string
+		changed        bool
+	}{
+		{"tidy.txt", "a<LF>b<LF>", "a<LF>b<LF>", false},
+		{"tidy.gz", "a<LF>b<LF>", "a<LF>b<LF>", false},
+		{"untidy.txt", "a  <LF>b<LF>", "a<LF>b<LF>", true},
+		{"untidy.gz", "a  <LF>b<LF>", "a<LF>b<LF>", true},
+		{"crlf.gz", "a<CRLF>b<LF>", "a<LF>b<LF>", true},
+	} {
+		path := path.Join(t.TempDir(), tc.name)
+		b := []byte(pre(tc.in))
+		if strings.HasSuffix(tc.name, ".gz") {
+			// as gzip(1) would, with a name and mtime that
+			// compressing again doesn't keep
+			buf := &bytes.Buffer{}
+			zw := gzip.NewWriter(buf)
+			zw.Name, zw.ModTime = tc.name, time.Unix(1e9, 0)
+			zw.Write(b)
+			zw.Close()
+			b = buf.Bytes()
+		}
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		r := convertFile(path)
+		if r.err != nil || r.changed != tc.changed {
+			t.Errorf("%s: convertFile = %+v; want changed %t", tc.name, r, tc.changed)
+		}
+		if _, err := os.Stat(path + backupFlag); (err == nil) != tc.changed {
+			t.Errorf("%s: backup made: %t; want %t", tc.name, err == nil, tc.changed)
+		}
+
+		f, err := textfile.OpenText(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err = io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := post(string(b)); got != tc.want {
+			t.Errorf("%s: got %s; want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Test_binary checks that binary input is refused, from stdin and
+// with -i, and a binary file is left as it is, though its first
+// NUL comes after the bytes ReadBOM reads ahead.
+func Test_binary(t *testing.T) {
+	inputs := []string{
+		"abcdef\x00\x00\x00<LF>more<LF>",
+		"\x89PNG<CRLF>\x1a<LF>\x00\x00\x00<CR>IHDR\x00\x00\x01\x00",
+		"\x7fELF\x02\x01\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00<LF><CRLF>",
+	}
+	forEach(t, func(t *testing.T, _ []testCase) {
+		for _, in := range inputs {
+			for _, r := range []io.Reader{strings.NewReader(pre(in)), &oneByteReader{strings.NewReader(pre(in))}} {
+				buf := &bytes.Buffer{}
+				if err := run(r, buf); !errors.Is(err, textfile.ErrBinary) || buf.Len() > 0 {
+					t.Errorf("run(%q) = %v, wrote %q; want %v and nothing", in, err, buf, textfile.ErrBinary)
+				}
+			}
+
+			path := path.Join(t.TempDir(), "in.bin")
+			if err := os.WriteFile(path, []byte(pre(in)), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if r := convertFile(path); !errors.Is(r.err, textfile.ErrBinary) || r.changed {
+				t.Errorf("convertFile(%q) = %+v; want %v, unchanged", in, r, textfile.ErrBinary)
+			}
+			if b, err := os.ReadFile(path); err != nil || string(b) != pre(in) {
+				t.Errorf("convertFile(%q) left %q, %v; want it as it was", in, b, err)
+			}
+		}
+	})
+}
+
+// widen encodes each byte of s as a 2-byte code unit.
+func widen(s string, order binary.ByteOrder) string {
+	b := make([]byte, 2*len(s))
+	for i := 0; i < len(s); i++ {
+		order.PutUint16(b[2*i:], uint16(s[i]))
+	}
+	return string(b)
+}
+
+// Test_directions converts mixed line endings in all six
+// directions, with reads small enough to split CRLFs, and as
+// UTF-16.
+func Test_directions(t *testing.T) {
+	inputs := []string{
+		"a<CRLF>b<LF>c<CR>d",
+		"<CR><CR><LF><LF><CR>",
+	}
+	for _, tc := range []struct {
+		from, to eol.Ending
+		wants    []string // one for each of inputs
+	}{
+		{eol.CRLF, eol.LF, []string{"a<LF>b<LF>c<CR>d", "<CR><LF><LF><CR>"}},
+		{eol.CRLF, eol.CR, []string{"a<CR>b<LF>c<CR>d", "<CR><CR><LF><CR>"}},
+		{eol.LF, eol.CRLF, []string{"a<CRLF>b<CRLF>c<CR>d", "<CR><CRLF><CRLF><CR>"}},
+		{eol.LF, eol.CR, []string{"a<CRLF>b<CR>c<CR>d", "<CR><CRLF><CR><CR>"}},
+		{eol.CR, eol.LF, []string{"a<CRLF>b<LF>c<LF>d", "<LF><CRLF><LF><LF>"}},
+		{eol.CR, eol.CRLF, []string{"a<CRLF>b<LF>c<CRLF>d", "<CRLF><CRLF><LF><CRLF>"}},
+	} {
+		for i, in := range inputs {
+			want := pre(tc.wants[i])
+			for _, size := range []int{1, 2, 3, defaultBufSize} {
+				buf := &bytes.Buffer{}
+				if err := _replaceBytes(strings.NewReader(pre(in)), buf, size, tc.to, tc.from); err != nil {
+					t.Fatalf("got non-nil err: %v", err)
+				}
+				if got := buf.String(); got != want {
+					t.Errorf("%s to %s, size %d\nin   %s\ngot  %q\nwant %q", tc.from, tc.to, size, in, got, want)
+				}
+			}
+
+			order := binary.LittleEndian
+			buf := &bytes.Buffer{}
+			if err := replaceUTF16(strings.NewReader(widen(pre(in), order)), buf, order, tc.to, tc.from); err != nil {
+				t.Fatalf("got non-nil err: %v", err)
+			}
+			if got := buf.String(); got != widen(want, order) {
+				t.Errorf("%s to %s, UTF-16\nin   %s\ngot  %q\nwant %q", tc.from, tc.to, in, got, widen(want, order))
+			}
+		}
+	}
+}
+
+// bufSizes are the read sizes the fuzz targets try: small enough
+// to split a CRLF, and the default.
+var bufSizes = []int{1, 2, 3, defaultBufSize}
+
+// crBeforeCRLF matches a CR before a CRLF, in bytes or UTF-16
+// code units.  A single pass turns it into a CRLF, so dos2unix
+// isn't idempotent for input with one.
+var crBeforeCRLF = []string{"\r\r\n", "\r\x00\r\x00\n\x00", "\x00\r\x00\r\x00\n"}
+
+// Fuzz_run checks that run, as dos2unix, gives the same output
+// however its input is split into reads, and that running it again
+// changes nothing, unless there's a CR before a CRLF.
+func Fuzz_run(f *testing.F) {
+	for _, tc := range dos2unixCases {
+		f.Add(pre(tc.in))
+	}
+	f.Add("\xff\xfea\x00\r\x00\n\x00\r\x00")
+	f.Add("\xef\xbb\xbf\r\n\r")
+
+	use(f, Dos2unix)
+	force = true
+	defer func() { force = false }()
+
+	f.Fuzz(func(t *testing.T, in string) {
+		once := &bytes.Buffer{}
+		if err := run(strings.NewReader(in), once); err != nil {
+			t.Fatal(err)
+		}
+
+		split := &bytes.Buffer{}
+		if err := run(&oneByteReader{strings.NewReader(in)}, split); err != nil {
+			t.Fatal(err)
+		}
+		if split.String() != once.String() {
+			t.Errorf("split reads\nin   %q\ngot  %q\nwant %q", in, split, once)
+		}
+
+		for _, seq := range crBeforeCRLF {
+			if strings.Contains(in, seq) {
+				return
+			}
+		}
+		twice := &bytes.Buffer{}
+		if err := run(bytes.NewReader(once.Bytes()), twice); err != nil {
+			t.Fatal(err)
+		}
+		if twice.String() != once.String() {
+			t.Errorf("not idempotent\nin     %q\nonce   %q\ntwice  %q", in, once, twice)
+		}
+	})
+}
+
+// Fuzz_dos2unix checks _replaceBytes, from CRLF to LF, against
+// dos2unixStrings, reading at each of bufSizes and a byte at a
+// time.
+func Fuzz_dos2unix(f *testing.F) {
+	for _, tc := range dos2unixCases {
+		f.Add(pre(tc.in))
+	}
+	f.Fuzz(func(t *testing.T, in string) {
+		want := &bytes.Buffer{}
+		if err := dos2unixStrings(strings.NewReader(in), want); err != nil {
+			t.Fatal(err)
+		}
+		for _, sp := range splits(in, bufSizes) {
+			got := &bytes.Buffer{}
+			if err := _replaceBytes(sp.in, got, sp.size, eol.LF, eol.CRLF); err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != want.String() {
+				t.Errorf("%s\nin   %q\ngot  %q\nwant %q", sp.name, in, got, want)
+			}
+		}
+	})
+}
+
+// Fuzz_unix2dos checks _replaceBytes, from LF to CRLF, against
+// unix2dosStrings, reading at each of bufSizes and a byte at a
+// time; that running it again changes nothing; and
+// that running dos2unix first changes nothing either, unless
+// there's a CR before a CRLF, which dos2unix's single pass turns
+// into a CRLF.
+func Fuzz_unix2dos(f *testing.F) {
+	for _, tc := range unix2dosCases {
+		f.Add(pre(tc.in))
+	}
+	f.Add("\r\r\n\n\r")
+
+	unix2dos := func(t *testing.T, in io.Reader, size int) string {
+		buf := &bytes.Buffer{}
+		if err := _replaceBytes(in, buf, size, eol.CRLF, eol.LF); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	f.Fuzz(func(t *testing.T, in string) {
+		ref := &bytes.Buffer{}
+		if err := unix2dosStrings(strings.NewReader(in), ref); err != nil {
+			t.Fatal(err)
+		}
+		want := ref.String()
+		for _, sp := range splits(in, bufSizes) {
+			if got := unix2dos(t, sp.in, sp.size); got != want {
+				t.Errorf("%s\nin   %q\ngot  %q\nwant %q", sp.name, in, got, want)
+			}
+		}
+
+		if twice := unix2dos(t, strings.NewReader(want), defaultBufSize); twice != want {
+			t.Errorf("not idempotent\nin     %q\nonce   %q\ntwice  %q", in, want, twice)
+		}
+
+		if strings.Contains(in, "\r\r\n") {
+			return
+		}
+		dos2unix := &bytes.Buffer{}
+		w := eol.NewWriter(dos2unix, eol.LF, eol.CRLF)
+		io.WriteString(w, in)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if got := unix2dos(t, dos2unix, defaultBufSize); got != want {
+			t.Errorf("unix2dos(dos2unix(in)) != unix2dos(in)\nin   %q\ngot  %q\nwant %q", in, got, want)
+		}
+	})
+}
+
+// Benchmark_performance reports each of commands' throughput in
+// MB/s over 100 MiB of text, a line every 10 bytes; see
+// benchmarks.txt for results, which set defaultBufSize.
+func Benchmark_performance(b *testing.B) {
+	const testSize = 100 * 1024 * 1024
+	for _, c := range commands {
+		b.Run(c.cmd.Name, func(b *testing.B) {
+			use(b, c.cmd)
+			from, _ := endings()
+			testFile := path.Join(b.TempDir(), "test.txt")
+			{
+				f, err := os.Create(testFile)
+				if err != nil {
+					b.Fatal(err)
+				}
+				w := bufio.NewWriter(f)
+				for i := range testSize {
+					switch i % 10 {
+					case 0:
+						w.Write(from.Bytes())
+					default:
+						w.Write([]byte{'a'})
+					}
+				}
+				if err := w.Flush(); err != nil {
+					b.Fatal(err)
+				}
+				f.Close()
+			}
+			// a CRLF is 2 bytes, so dos2unix's text is bigger
+			fi, err := os.Stat(testFile)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			run := func(b *testing.B, replace func(io.Reader, io.Writer) error) {
+				b.SetBytes(fi.Size())
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					f, err := os.Open(testFile)
+					if err != nil {
+						b.Fatal(err)
+					}
+					b.StartTimer()
+					err = replace(f, io.Discard)
+					f.Close()
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+
+			b.Run("replaceStrings", func(b *testing.B) { run(b, c.replaceStrings) })
+
+			b.Run("replaceBytes", func(b *testing.B) {
+				multiplier := 1
+				for range 7 {
+					size := 1024 * multiplier
+					name := fmt.Sprintf("size_%dK", multiplier)
+					b.Run(name, func(b *testing.B) {
+						run(b, func(in io.Reader, out io.Writer) error {
+							return replaceBytesSize(in, out, size)
+						})
+					})
+					multiplier *= 2
+				}
+			})
+		})
+	}
+}
+
+// Benchmark_unchanged converts 100 MiB of text that is already
+// CRLF, to a file, as when redirected.  convert passes it through
+// with io.Copy, and convertFile writes nothing; see
+// benchmarks.txt for results.
+func Benchmark_unchanged(b *testing.B) {
+	use(b, Unix2dos)
+	const testSize = 100 * 1024 * 1024
+	dir := b.TempDir()
+	testFile := path.Join(dir, "test.txt")
+	{
+		f, err := os.Create(testFile)
+		if err != nil {
+			b.Fatal(err)
+		}
+		w := bufio.NewWriter(f)
+		for i := range testSize {
+			switch i % 10 {
+			case 8:
+				w.WriteByte('\r')
+			case 9:
+				w.WriteByte('\n')
+			default:
+				w.WriteByte('a')
+			}
+		}
+		if err := w.Flush(); err != nil {
+			b.Fatal(err)
+		}
+		f.Close()
+	}
+
+	bench := func(b *testing.B, replace func(io.Reader, io.Writer) error) {
+		b.SetBytes(testSize)
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			in, err := os.Open(testFile)
+			if err != nil {
+				b.Fatal(err)
+			}
+			out, err := os.Create(path.Join(dir, "out.txt"))
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StartTimer()
+			err = replace(in, out)
+			in.Close()
+			out.Close()
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("replaceBytesSize", func(b *testing.B) {
+		bench(b, func(in io.Reader, out io.Writer) error {
+			return replaceBytesSize(in, out, defaultBufSize)
+		})
+	})
+	b.Run("convert", func(b *testing.B) { bench(b, run) })
+	b.Run("convertFile", func(b *testing.B) {
+		b.SetBytes(testSize)
+		for i := 0; i < b.N; i++ {
+			if r := convertFile(testFile); r.err != nil || r.changed {
+				b.Fatalf("convertFile = %+v; want it unchanged", r)
+			}
+		}
+	})
+}
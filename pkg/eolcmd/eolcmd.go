@@ -0,0 +1,324 @@
+// Package eolcmd is the command line shared by dos2unix and
+// unix2dos: its flags, and the drivers that convert, check, diff
+// and restore files with them.  A Command sets the name, and the
+// line endings converted by default; its Main is the whole
+// program:
+//
+//	func main() { eolcmd.Dos2unix.Main() }
+//
+// Usage:
+//
+//	dos2unix [options] [file]
+//	dos2unix -i [options] file...
+//	dos2unix -i -r [options] path...
+//	dos2unix -info [-json] [-r] [file...]
+//	dos2unix -check [options] [file...]
+//	dos2unix -diff [options] [file...]
+//	dos2unix -undo -backup suffix [-r] path...
+//
+// and the same for unix2dos.  Use -from and -to to convert between
+// any of LF, CRLF and bare CR (classic Mac OS) line endings; e.g.,
+// -from cr -to lf is mac2unix.
+//
+// A command reads from file, or stdin, and prints to stdout; from a
+// file, text up to the first line ending to convert is copied as
+// is, by the kernel where it can.  With -i, it converts each file in
+// place, leaving files that need no conversion untouched, and
+// unwritten.  With -j, it converts up to N files at once, still
+// reporting them in order.  With -backup, each file that changes is
+// first copied to its name plus suffix, e.g., -backup .bak.  With
+// -undo, it puts each file's backup back in its place, or with -r,
+// every backup under each directory.  A file that fails to convert
+// is left as it was, with no backup.  With -r, it also converts the
+// files under each directory, skipping VCS and node_modules
+// directories, and prints a summary.  Globs match a file's base name
+// or its path below the directory; -include and -exclude can be
+// repeated.
+//
+// Symlinks are skipped with a warning, unless -follow-symlinks,
+// which converts the file a link points to and leaves the link as it
+// is; -r never descends into a linked directory.  FIFOs, devices and
+// sockets are refused.  A converted file keeps its mode, and its
+// owner and group where permitted.  A file with other hard links is
+// replaced, which breaks the links, unless -hardlinks write, which
+// writes the conversion through to every link, though not
+// atomically.
+//
+// With -info, a command prints the line endings found in each file,
+// or stdin, as a table or as JSON, and changes nothing.  With -check,
+// it prints where each file, or stdin, would first be changed by
+// converting it, changes nothing, and exits with status 1 if any
+// would be.  With -diff, or -n, it prints a unified diff of what
+// converting each file, or stdin, would change, with line endings
+// shown as \r\n, \n and \r, and changes nothing.
+//
+// Input that looks binary is skipped with a warning, unless -force.
+// Skipping input, whether a file or stdin, doesn't make the exit
+// status 1.
+//
+// Compressed input, told by its magic bytes or by a .gz or .zst
+// name, is decompressed and converted.  -i compresses it again as it
+// was, and so does -recompress when printing to stdout; -info,
+// -check and -diff look at the text inside.  Only gzip is supported:
+// zstd is skipped with a warning.
+//
+// A byte order mark (BOM) is kept as found, unless -add-bom or
+// -remove-bom.  UTF-16 input, told by its BOM, is converted by 2-byte
+// code unit.
+//
+// In the same pass, -trim-space strips spaces and tabs from the end
+// of each line, -final-newline ends a last line that has no line
+// ending, and -squeeze-blank collapses blank lines at the end into
+// one.
+//
+// With -config, -i, -check and -diff take each file's line ending
+// from its .gitattributes and .editorconfig, found by searching up
+// from the file, as git and editors would: eol=lf or eol=crlf, or
+// else end_of_line, and convert every other line ending to it.
+// Files marked -text or binary are skipped.  Files no config covers
+// are converted as -from and -to say.
+package eolcmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"zacharysyoung/CLUtils/pkg/cli"
+	"zacharysyoung/CLUtils/pkg/eol"
+	"zacharysyoung/CLUtils/pkg/textfile"
+)
+
+// Command is a line-ending converter: its name, what its usage
+// says it does, and the line endings it converts by default.
+type Command struct {
+	Name     string
+	Does     string // the usage sentence saying what it converts
+	From, To string // the defaults for -from and -to
+}
+
+// The commands.
+var (
+	Dos2unix = Command{
+		Name: "dos2unix",
+		Does: "Transforms the input, converting all carriage return line feeds (CRLF) to line feeds (LF).",
+		From: "crlf",
+		To:   "lf",
+	}
+	Unix2dos = Command{
+		Name: "unix2dos",
+		Does: "Transforms the input, converting all line feeds (LF) to carriage return line feeds (CRLF).",
+		From: "lf",
+		To:   "crlf",
+	}
+)
+
+// usage prints c's usage and exits with cli.ExitUsage.
+func (c Command) usage() {
+	fmt.Fprintf(os.Stderr, `usage: %s [options] [file]
+       %s -i [options] file...
+       %s -i -r [options] path...
+       %s -info [-json] [-r] [file...]
+       %s -check [options] [file...]
+       %s -diff [options] [file...]
+       %s -undo -backup suffix [-r] path...
+
+%s
+
+`,
+		c.Name, c.Name, c.Name, c.Name, c.Name, c.Name, c.Name, c.Does)
+
+	flag.PrintDefaults()
+	os.Exit(cli.ExitUsage)
+}
+
+// The flags, as parsed in Main.
+var (
+	inPlace, keepMtime, undoMode, recursive, infoMode, jsonFlag,
+	checkMode, diffMode, force, keepBOM, addBOM, removeBOM,
+	trimSpace, finalNewline, squeezeBlank, followLinks,
+	recompress, configFlag bool
+
+	backupFlag, fromFlag, toFlag, hardlinks string
+
+	jobs int
+
+	include, exclude textfile.Patterns
+)
+
+// flags defines the command line flags, with c's defaults for
+// -from and -to.
+func (c Command) flags() {
+	flag.BoolVar(&inPlace, "i", false, "convert files in place")
+	flag.BoolVar(&keepMtime, "k", false, "keep each file's modification time (with -i)")
+	flag.StringVar(&backupFlag, "backup", "", "copy each file that changes to its name plus `suffix` (with -i or -undo)")
+	flag.BoolVar(&undoMode, "undo", false, "restore files from their -backup copies")
+	flag.BoolVar(&recursive, "r", false, "convert files under directories (with -i, -info, -check or -diff)")
+	flag.BoolVar(&infoMode, "info", false, "print line-ending counts instead of converting")
+	flag.BoolVar(&jsonFlag, "json", false, "print -info as JSON")
+	flag.BoolVar(&checkMode, "check", false, "exit with status 1 if any input needs converting")
+	flag.BoolVar(&diffMode, "diff", false, "print a diff of what converting would change instead of converting")
+	flag.BoolVar(&force, "force", false, "convert input even if it looks binary")
+	flag.BoolVar(&keepBOM, "keep-bom", false, "keep any byte order mark as found (default)")
+	flag.BoolVar(&addBOM, "add-bom", false, "add a UTF-8 byte order mark to input without one")
+	flag.BoolVar(&removeBOM, "remove-bom", false, "remove any byte order mark")
+	flag.StringVar(&fromFlag, "from", c.From, "the line `ending` to convert: lf, crlf or cr")
+	flag.StringVar(&toFlag, "to", c.To, "the line `ending` to convert to: lf, crlf or cr")
+	flag.BoolVar(&trimSpace, "trim-space", false, "strip spaces and tabs from the end of each line")
+	flag.BoolVar(&finalNewline, "final-newline", false, "add a line ending to a last line without one")
+	flag.BoolVar(&squeezeBlank, "squeeze-blank", false, "collapse blank lines at the end into one")
+	flag.IntVar(&jobs, "j", 1, "convert up to `N` files at once (with -i)")
+	flag.BoolVar(&followLinks, "follow-symlinks", false, "convert the files symlinks point to instead of skipping them (with -i, -undo or -r)")
+	flag.StringVar(&hardlinks, "hardlinks", "break", "`how` to convert a file with other hard links: break the links, or write through them (with -i)")
+	flag.BoolVar(&recompress, "recompress", false, "compress the output as the input was (without -i, which always does)")
+	flag.BoolVar(&configFlag, "config", false, "take each file's line ending from .gitattributes and .editorconfig (with -i, -check or -diff)")
+	flag.BoolVar(&diffMode, "n", false, "same as -diff")
+	flag.Var(&include, "include", "convert only files matching `glob` (with -r)")
+	flag.Var(&exclude, "exclude", "don't convert files or directories matching `glob` (with -r)")
+}
+
+// Main runs c: it parses the command line, then converts, checks
+// or restores as the flags say, and exits with a status from cli
+// if anything failed.
+func (c Command) Main() {
+	c.flags()
+	cli.Parse(c.Name, c.usage)
+
+	for _, name := range []string{fromFlag, toFlag} {
+		if _, err := eol.Parse(name); err != nil {
+			cli.BadArgs(err.Error())
+		}
+	}
+	if fromFlag == toFlag {
+		cli.BadArgs("-from and -to are the same")
+	}
+
+	if count(keepBOM, addBOM, removeBOM) > 1 {
+		cli.BadArgs("only one of -keep-bom, -add-bom and -remove-bom")
+	}
+
+	if count(inPlace, infoMode, checkMode, diffMode, undoMode) > 1 {
+		cli.BadArgs("only one of -i, -info, -check, -diff and -undo")
+	}
+	if backupFlag != "" && !inPlace && !undoMode {
+		cli.BadArgs("-backup needs -i or -undo")
+	}
+	if undoMode && backupFlag == "" {
+		cli.BadArgs("-undo needs -backup")
+	}
+	if keepMtime && !inPlace {
+		cli.BadArgs("-k needs -i")
+	}
+	if jobs < 1 {
+		cli.BadArgs("-j needs at least 1")
+	}
+	if jobs != 1 && !inPlace {
+		cli.BadArgs("-j needs -i")
+	}
+	if hardlinks != "break" && hardlinks != "write" {
+		cli.BadArgs(fmt.Sprintf("-hardlinks must be break or write, not %q", hardlinks))
+	}
+	if hardlinks != "break" && !inPlace {
+		cli.BadArgs("-hardlinks needs -i")
+	}
+	if followLinks && !inPlace && !undoMode && !recursive {
+		cli.BadArgs("-follow-symlinks needs -i, -undo or -r")
+	}
+	if (len(include) > 0 || len(exclude) > 0) && !recursive {
+		cli.BadArgs("-include and -exclude need -r")
+	}
+	if recompress && count(inPlace, infoMode, checkMode, diffMode, undoMode) > 0 {
+		cli.BadArgs("-recompress is for printing to stdout; -i always recompresses")
+	}
+	if configFlag && !inPlace && !checkMode && !diffMode {
+		cli.BadArgs("-config needs -i, -check or -diff")
+	}
+	if jsonFlag && !infoMode {
+		cli.BadArgs("-json needs -info")
+	}
+
+	tail := flag.Args()
+	if recursive && !inPlace && !infoMode && !checkMode && !diffMode && !undoMode {
+		cli.BadArgs("-r needs -i, -info, -check, -diff or -undo")
+	}
+	if infoMode {
+		if !printInfo(tail) {
+			os.Exit(cli.ExitFailure)
+		}
+		return
+	}
+	if diffMode {
+		if !diffFiles(tail) {
+			os.Exit(cli.ExitFailure)
+		}
+		return
+	}
+	if checkMode {
+		if !checkFiles(tail) {
+			os.Exit(cli.ExitFailure)
+		}
+		return
+	}
+	if undoMode {
+		if len(tail) == 0 {
+			cli.BadArgs("-undo needs at least one file")
+		}
+		if sum := undoFiles(tail); sum.Failed > 0 {
+			os.Exit(cli.ExitFailure)
+		}
+		return
+	}
+	if inPlace {
+		if len(tail) == 0 {
+			cli.BadArgs("-i needs at least one file")
+		}
+		sum := convertFiles(tail)
+		if recursive {
+			fmt.Fprintln(os.Stderr, sum)
+		}
+		if sum.Failed > 0 {
+			os.Exit(cli.ExitFailure)
+		}
+		return
+	}
+
+	var (
+		in   io.Reader
+		name = "stdin"
+		err  error
+	)
+	switch len(tail) {
+	case 0:
+		in = os.Stdin
+	case 1:
+		name = tail[0]
+		in, err = os.Open(tail[0])
+		if err != nil {
+			cli.ErrorOut(fmt.Sprintf("could not read from specified file: %v", err))
+		}
+		defer in.(*os.File).Close()
+	default:
+		cli.BadArgs(fmt.Sprintf("got %d files: %s; can only read from Stdin or a single file; use -i to convert many files in place", len(tail), strings.Join(tail, ", ")))
+	}
+
+	err = withCompression(name, in, os.Stdout, recompress, run)
+	switch {
+	case errors.Is(err, textfile.ErrBinary), errors.Is(err, textfile.ErrUnsupported):
+		cli.Warn(fmt.Sprintf("skipping %s: %v", name, err))
+	case err != nil:
+		cli.ErrorOut(err.Error())
+	}
+}
+
+// count returns how many of flags are set.
+func count(flags ...bool) (n int) {
+	for _, f := range flags {
+		if f {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,190 @@
+package eolcmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"zacharysyoung/CLUtils/pkg/cli"
+	"zacharysyoung/CLUtils/pkg/textfile"
+)
+
+// convertFiles converts each file in place, up to -j at once,
+// reporting errors in the order of args.  With -r, directories
+// are walked for files to convert.
+func convertFiles(args []string) textfile.Summary {
+	paths := args
+	var sum textfile.Summary
+	if recursive {
+		paths, sum = walk(args)
+	}
+
+	forEachFile(paths, jobs, convertFile, func(path string, r converted) {
+		sum.Scanned++
+		switch {
+		case r.skip:
+			sum.Skipped++
+		case errors.Is(r.err, textfile.ErrBinary), errors.Is(r.err, textfile.ErrSymlink),
+			errors.Is(r.err, textfile.ErrUnsupported):
+			cli.Warn(fmt.Sprintf("skipping %v", r.err))
+			sum.Skipped++
+		case r.err != nil:
+			cli.Error(r.err.Error())
+			sum.Failed++
+		case r.changed:
+			sum.Converted++
+		}
+	})
+	return sum
+}
+
+// forEachFile calls do with each of paths, on up to jobs goroutines
+// at once.  It calls report with each path and its result in the
+// order of paths, as soon as that path and those before it are
+// done, so output is the same however the work is scheduled.
+// Report is only ever called from the calling goroutine.
+func forEachFile[T any](paths []string, jobs int, do func(path string) T, report func(path string, result T)) {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	results := make([]chan T, len(paths))
+	for i := range results {
+		results[i] = make(chan T, 1)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(jobs, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] <- do(paths[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range paths {
+			next <- i
+		}
+		close(next)
+	}()
+
+	for i, path := range paths {
+		report(path, <-results[i])
+	}
+	wg.Wait()
+}
+
+// converted is what became of a file convertFile was given.
+type converted struct {
+	changed, skip bool
+	err           error
+}
+
+// convertFile converts the file at path in place; convert, with the
+// line endings for path, is the unit of work.  A compressed file
+// is compressed again.
+func convertFile(path string) converted {
+	from, to, skip, err := endingsFor(path)
+	if skip || err != nil {
+		return converted{skip: skip, err: err}
+	}
+	conv := func(in io.Reader, out io.Writer) error {
+		return convert(in, out, to, from...)
+	}
+
+	opts := textfile.Options{
+		KeepMtime:      keepMtime,
+		Backup:         backupFlag,
+		FollowSymlinks: followLinks,
+		WriteThrough:   hardlinks == "write",
+		Unchanged: func(r io.Reader) (same bool, err error) {
+			err = withCompression(path, r, io.Discard, false, func(in io.Reader, _ io.Writer) error {
+				same, err = unchanged(in, to, from...)
+				return err
+			})
+			return same, err
+		},
+	}
+	changed, err := textfile.Rewrite(path, func(in io.Reader, out io.Writer) error {
+		return withCompression(path, in, out, true, conv)
+	}, opts)
+	return converted{changed: changed, err: err}
+}
+
+// undoFiles restores each file from its backup, or with -r, every
+// backup under each directory, reporting errors as it goes.
+func undoFiles(args []string) textfile.Summary {
+	paths := args
+	var sum textfile.Summary
+	if recursive {
+		paths, sum = walkBackups(args)
+	}
+
+	for _, path := range paths {
+		sum.Scanned++
+		if followLinks {
+			// the backup was made next to the link's target
+			if target, err := filepath.EvalSymlinks(path); err == nil {
+				path = target
+			}
+		}
+		if err := textfile.Restore(path, backupFlag); err != nil {
+			cli.Error(err.Error())
+			sum.Failed++
+			continue
+		}
+		sum.Converted++
+	}
+	return sum
+}
+
+// walkBackups returns the files under args that have backups,
+// named by the backup less its suffix.  -exclude still applies.
+func walkBackups(args []string) (paths []string, sum textfile.Summary) {
+	filter := textfile.Filter{Exclude: exclude}
+	for _, arg := range args {
+		files, _, err := filter.Walk(arg)
+		if err != nil {
+			cli.Error(err.Error())
+			sum.Failed++
+			continue
+		}
+		for _, f := range files {
+			if strings.HasSuffix(f, backupFlag) {
+				paths = append(paths, strings.TrimSuffix(f, backupFlag))
+			}
+		}
+	}
+	return paths, sum
+}
+
+// walk expands args into the files under them that pass -include
+// and -exclude, less any -backup copies.  Sum counts what was
+// skipped along the way.
+func walk(args []string) (paths []string, sum textfile.Summary) {
+	filter := textfile.Filter{Include: include, Exclude: exclude, FollowSymlinks: followLinks}
+	for _, arg := range args {
+		files, skipped, err := filter.Walk(arg)
+		if err != nil {
+			cli.Error(err.Error())
+			sum.Failed++
+			continue
+		}
+		for _, f := range files {
+			if backupFlag != "" && strings.HasSuffix(f, backupFlag) {
+				skipped++
+				continue
+			}
+			paths = append(paths, f)
+		}
+		sum.Scanned += skipped
+		sum.Skipped += skipped
+	}
+	return paths, sum
+}
@@ -0,0 +1,46 @@
+package eolcmd
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_forEachFile(t *testing.T) {
+	var paths, want []string
+	for i := range 50 {
+		paths = append(paths, fmt.Sprint(i))
+		want = append(want, fmt.Sprint(i, ":", 2*i))
+	}
+
+	for _, jobs := range []int{0, 1, 4, 100} {
+		var running, most atomic.Int32
+		do := func(path string) int {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := most.Load()
+				if n <= m || most.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			var i int
+			fmt.Sscan(path, &i)
+			time.Sleep(time.Duration(len(paths)-i) * 10 * time.Microsecond) // finish out of order
+			return 2 * i
+		}
+
+		var got []string
+		forEachFile(paths, jobs, do, func(path string, n int) {
+			got = append(got, fmt.Sprint(path, ":", n))
+		})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("jobs %d: reported\n%v\nwant\n%v", jobs, got, want)
+		}
+		if limit := int32(max(jobs, 1)); most.Load() > limit {
+			t.Errorf("jobs %d: %d ran at once", jobs, most.Load())
+		}
+	}
+}
@@ -3,7 +3,7 @@
 //
 // Use the F() and D() funcs to create files and directories.
 // Both take a name argument. D can also take any number of
-// F, or none for an empty directory.
+// F, or none for an empty directory.  L() creates a symlink.
 //
 // To create the tree:
 //
@@ -43,6 +43,9 @@ func NewTree(files ...File) (tree *Tree, tempPath string, err error) {
 func (t *Tree) Debug() string {
 	var print func(File) string
 	print = func(f File) string {
+		if f.target != "" {
+			return `L("` + f.name + `", "` + f.target + `")`
+		}
 		if f.children == nil {
 			return `F("` + f.name + `")`
 		}
@@ -77,11 +80,16 @@ func (t *Tree) makeTemp() (string, error) {
 	walk = func(n File, path string) error {
 		path = filepath.Join(path, n.name)
 
-		if n.children != nil {
+		switch {
+		case n.target != "":
+			if err := os.Symlink(n.target, path); err != nil {
+				return err
+			}
+		case n.children != nil:
 			if err := os.Mkdir(path, 0700); err != nil {
 				return err
 			}
-		} else {
+		default:
 			if err := touch(path); err != nil {
 				return err
 			}
@@ -119,11 +127,19 @@ func (t *Tree) Remove() error { return os.RemoveAll(t.tempPath) }
 type File struct {
 	name     string
 	children []File
+	target   string // for a symlink
 }
 
 // F wraps name in a File
 func F(name string) File {
-	return File{name, nil}
+	return File{name: name}
+}
+
+// L creates a symlink File with name, pointing to target.  The
+// target is used as is, so a relative one is relative to the
+// link's directory, and it need not exist.
+func L(name, target string) File {
+	return File{name: name, target: target}
 }
 
 // D creates a directory File with name, and children files.  If files
@@ -132,7 +148,7 @@ func D(name string, files ...File) File {
 	if files == nil {
 		files = []File{}
 	}
-	return File{name, files}
+	return File{name: name, children: files}
 }
 
 func (f File) String() string {
@@ -141,6 +157,9 @@ func (f File) String() string {
 
 // print a compact, diagnostic string for f.
 func (f File) print() string {
+	if f.target != "" {
+		return f.name + "->" + f.target
+	}
 	if f.children == nil {
 		return f.name
 	}
@@ -78,6 +78,44 @@ func TestNewTree(t *testing.T) {
 	}
 }
 
+func TestNewTreeSymlink(t *testing.T) {
+	tree, tempPath, err := NewTree(
+		F("f1"),
+		L("l1", "f1"),
+		D("d2",
+			L("l2", "../f1"),
+			L("dangling", "nowhere")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Remove()
+
+	for _, tc := range []struct {
+		path, target string
+	}{
+		{"l1", "f1"},
+		{"d2/l2", "../f1"},
+		{"d2/dangling", "nowhere"},
+	} {
+		path := filepath.Join(tempPath, tc.path)
+		fi, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode()&fs.ModeSymlink == 0 {
+			t.Errorf("%s has mode %v; want a symlink", tc.path, fi.Mode())
+		}
+		if got, _ := os.Readlink(path); got != tc.target {
+			t.Errorf("%s points to %q; want %q", tc.path, got, tc.target)
+		}
+	}
+
+	if fi, err := os.Stat(filepath.Join(tempPath, "d2/l2")); err != nil || !fi.Mode().IsRegular() {
+		t.Errorf("d2/l2 should resolve to the regular file f1; got %v, %v", fi, err)
+	}
+}
+
 func TestString(t *testing.T) {
 	for _, tc := range []struct {
 		f    File
@@ -97,6 +135,12 @@ func TestString(t *testing.T) {
 			),
 			want: "root[f1 d2[d3[]] f4]",
 		},
+		{
+			f: D("root",
+				F("f1"),
+				L("l2", "f1")),
+			want: "root[f1 l2->f1]",
+		},
 	} {
 		if got := tc.f.String(); got != tc.want {
 			t.Errorf("got %s; want %s", got, tc.want)
@@ -119,6 +163,11 @@ func TestDebug(t *testing.T) {
 			files: []File{F("f1"), D("d2", F("f3"), D("d4"))},
 			want:  `NewTree(F("f1"), D("d2", F("f3"), D("d4")))`,
 		},
+
+		{
+			files: []File{F("f1"), L("l2", "f1")},
+			want:  `NewTree(F("f1"), L("l2", "f1"))`,
+		},
 	} {
 		tree, _, _ := NewTree(tc.files...)
 		if got := tree.Debug(); got != tc.want {
@@ -0,0 +1,81 @@
+package textfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrBinary is returned by CheckText for input that looks binary.
+var ErrBinary = errors.New("looks like a binary file")
+
+// sniffLen is how much of a file IsBinary looks at, the same as
+// git.
+const sniffLen = 8000
+
+// IsBinary reports whether b, the start of a file, looks binary:
+// it holds a NUL byte, or more than one in ten of its bytes are
+// control characters not normally found in text.  Bytes past
+// sniffLen are not looked at.
+func IsBinary(b []byte) bool {
+	if len(b) > sniffLen {
+		b = b[:sniffLen]
+	}
+	if bytes.IndexByte(b, 0) >= 0 {
+		return true
+	}
+
+	odd := 0
+	for _, c := range b {
+		switch {
+		case c == '\t', c == '\n', c == '\r', c == '\f', c == '\b', c == 0x1b: // ESC, for ANSI colors
+		case c < 0x20, c == 0x7f:
+			odd++
+		}
+	}
+	return odd*10 > len(b)
+}
+
+// isBinaryUTF16 is IsBinary for UTF-16 text, where NUL bytes
+// are expected, so it looks at 2-byte code units instead.
+func isBinaryUTF16(b []byte, order binary.ByteOrder) bool {
+	if len(b) > sniffLen {
+		b = b[:sniffLen]
+	}
+
+	odd, n := 0, len(b)/2
+	for i := 0; i+1 < len(b); i += 2 {
+		switch c := order.Uint16(b[i:]); {
+		case c == 0:
+			return true
+		case c == '\t', c == '\n', c == '\r', c == '\f', c == '\b', c == 0x1b:
+		case c < 0x20, c == 0x7f:
+			odd++
+		}
+	}
+	return odd*10 > n
+}
+
+// CheckText returns ErrBinary if the start of r, up to sniffLen
+// bytes, looks binary for text in enc, see IsBinary.  Otherwise
+// the returned reader yields all of r.
+//
+// It reads until it has sniffLen bytes or r ends, however r splits
+// its reads, so input from a pipe is checked once that much has
+// arrived.
+func CheckText(r io.Reader, enc Encoding) (io.Reader, error) {
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	isBinary := IsBinary(buf[:n])
+	if order := enc.ByteOrder(); order != nil {
+		isBinary = isBinaryUTF16(buf[:n], order)
+	}
+	if isBinary {
+		return nil, ErrBinary
+	}
+	return io.MultiReader(bytes.NewReader(buf[:n]), r), nil
+}
@@ -0,0 +1,96 @@
+package textfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestIsBinary(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   []byte
+		want bool
+	}{
+		{"empty", []byte{}, false},
+		{"ascii", []byte("foo\r\nbar\n\tbaz\r"), false},
+		{"utf-8", []byte("héllo, 世界\n"), false},
+		{"latin-1", []byte("h\xe9llo\n"), false},
+		{"ansi colors", []byte("\x1b[31mred\x1b[0m\n"), false},
+		{"form feed", []byte("page 1\f page 2\n"), false},
+		{"nul", []byte("foo\x00bar"), true},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), true},
+		{"few controls", []byte("abcdefghijklmnopqrs\x01"), false},
+		{"many controls", []byte("abc\x01\x02\x03\x04\x05"), true},
+		{"nul past sniffLen", append(bytes.Repeat([]byte("a"), sniffLen), 0), false},
+	} {
+		if got := IsBinary(tc.in); got != tc.want {
+			t.Errorf("IsBinary(%s) = %t; want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIsBinaryUTF16(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   []byte
+		want bool
+	}{
+		{"empty", []byte{}, false},
+		{"ascii", []byte("f\x00o\x00\r\x00\n\x00"), false},
+		{"cjk", []byte("\x16\x4e\x4c\x75\n\x00"), false},
+		{"nul unit", []byte("f\x00\x00\x00o\x00"), true},
+		{"many controls", []byte("\x01\x00\x02\x00a\x00"), true},
+	} {
+		if got := isBinaryUTF16(tc.in, binary.LittleEndian); got != tc.want {
+			t.Errorf("isBinaryUTF16(%s) = %t; want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCheckText(t *testing.T) {
+	in := strings.Repeat("foo\r\n", sniffLen)
+	r, err := CheckText(strings.NewReader(in), Plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != in {
+		t.Errorf("CheckText's reader didn't yield all of the input")
+	}
+
+	for _, tc := range []struct {
+		in   string
+		enc  Encoding
+		want error
+	}{
+		{"foo\x00bar", Plain, ErrBinary},
+		{"foo\x00bar", UTF8, ErrBinary},
+		{"f\x00o\x00o\x00", UTF16LE, nil},
+		{"\x00f\x00o\x00o", UTF16BE, nil},
+	} {
+		if _, err := CheckText(strings.NewReader(tc.in), tc.enc); err != tc.want {
+			t.Errorf("CheckText(%q, %s) err = %v; want %v", tc.in, tc.enc, err, tc.want)
+		}
+	}
+
+	// after ReadBOM, the first read returns only what it read ahead
+	for _, in := range []string{"abcdef\x00\x00\x00\nmore\n", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"} {
+		_, r, err := ReadBOM(strings.NewReader(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CheckText(r, Plain); err != ErrBinary {
+			t.Errorf("CheckText(ReadBOM(%q)) err = %v; want %v", in, err, ErrBinary)
+		}
+		if _, err := CheckText(iotest.OneByteReader(strings.NewReader(in)), Plain); err != ErrBinary {
+			t.Errorf("CheckText(%q), a byte at a time, err = %v; want %v", in, err, ErrBinary)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package textfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// Encoding is a text encoding, as told by a byte order mark.
+type Encoding int
+
+const (
+	Plain   Encoding = iota // no BOM; ASCII, UTF-8, Latin-1, ...
+	UTF8                    // UTF-8 with a BOM
+	UTF16LE                 // UTF-16, little endian
+	UTF16BE                 // UTF-16, big endian
+)
+
+var boms = map[Encoding][]byte{
+	UTF8:    {0xef, 0xbb, 0xbf},
+	UTF16LE: {0xff, 0xfe},
+	UTF16BE: {0xfe, 0xff},
+}
+
+// BOM returns e's byte order mark, or nil for Plain.
+func (e Encoding) BOM() []byte { return boms[e] }
+
+// ByteOrder returns the order of e's 2-byte code units, or nil if
+// e is not UTF-16.
+func (e Encoding) ByteOrder() binary.ByteOrder {
+	switch e {
+	case UTF16LE:
+		return binary.LittleEndian
+	case UTF16BE:
+		return binary.BigEndian
+	}
+	return nil
+}
+
+func (e Encoding) String() string {
+	switch e {
+	case UTF8:
+		return "UTF-8"
+	case UTF16LE:
+		return "UTF-16LE"
+	case UTF16BE:
+		return "UTF-16BE"
+	}
+	return "plain"
+}
+
+// MarshalText makes e a string in JSON.
+func (e Encoding) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// ReadBOM reads the byte order mark, if any, from the start of r.
+// The returned reader yields the rest of r, after the BOM.
+func ReadBOM(r io.Reader) (Encoding, io.Reader, error) {
+	buf := make([]byte, 3)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Plain, nil, err
+	}
+	buf = buf[:n]
+
+	enc := Plain
+	for _, e := range []Encoding{UTF8, UTF16LE, UTF16BE} {
+		if bytes.HasPrefix(buf, e.BOM()) {
+			enc = e
+			break
+		}
+	}
+	return enc, io.MultiReader(bytes.NewReader(buf[len(enc.BOM()):]), r), nil
+}
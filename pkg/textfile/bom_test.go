@@ -0,0 +1,37 @@
+package textfile
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadBOM(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		want     Encoding
+		wantRest string
+	}{
+		{"", Plain, ""},
+		{"a", Plain, "a"},
+		{"foo", Plain, "foo"},
+		{"\xef\xbb\xbffoo", UTF8, "foo"},
+		{"\xef\xbb\xbf", UTF8, ""},
+		{"\xef\xbbfoo", Plain, "\xef\xbbfoo"},
+		{"\xff\xfef\x00", UTF16LE, "f\x00"},
+		{"\xfe\xff\x00f", UTF16BE, "\x00f"},
+		{"\xfe\xff", UTF16BE, ""},
+	} {
+		enc, r, err := ReadBOM(strings.NewReader(tc.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rest, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if enc != tc.want || string(rest) != tc.wantRest {
+			t.Errorf("ReadBOM(%q) = %s, %q; want %s, %q", tc.in, enc, rest, tc.want, tc.wantRest)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package textfile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrUnsupported is returned for compression that is recognized,
+// but can't be read or written.
+var ErrUnsupported = errors.New("unsupported compression")
+
+// Compression is how a file is compressed, as told by its magic
+// bytes or its name.
+type Compression int
+
+const (
+	Uncompressed Compression = iota
+	Gzip                     // .gz
+	Zstd                     // .zst; recognized, but unsupported
+)
+
+var magics = map[Compression][]byte{
+	Gzip: {0x1f, 0x8b},
+	Zstd: {0x28, 0xb5, 0x2f, 0xfd},
+}
+
+var exts = map[Compression]string{
+	Gzip: ".gz",
+	Zstd: ".zst",
+}
+
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	}
+	return "none"
+}
+
+// DetectCompression tells how the file named name, read from r,
+// is compressed: by its magic bytes, or else by the extension of
+// name, which may be empty, e.g., for stdin.  The returned reader
+// yields all of r.  If r is an io.Seeker, it is rewound and
+// returned as is, so an *os.File stays one.
+func DetectCompression(name string, r io.Reader) (Compression, io.Reader, error) {
+	buf := make([]byte, 4)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Uncompressed, nil, err
+	}
+	buf = buf[:n]
+
+	c := Uncompressed
+	for _, cc := range []Compression{Gzip, Zstd} {
+		if bytes.HasPrefix(buf, magics[cc]) || strings.HasSuffix(name, exts[cc]) {
+			c = cc
+			break
+		}
+	}
+
+	if s, ok := r.(io.Seeker); ok {
+		if _, err := s.Seek(int64(-n), io.SeekCurrent); err == nil {
+			return c, r, nil
+		}
+	}
+	return c, io.MultiReader(bytes.NewReader(buf), r), nil
+}
+
+// Reader returns a reader of what r decompresses to.
+func (c Compression) Reader(r io.Reader) (io.ReadCloser, error) {
+	switch c {
+	case Uncompressed:
+		return io.NopCloser(r), nil
+	case Gzip:
+		return gzip.NewReader(r)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, c)
+}
+
+// Writer returns a writer that compresses what is written to it
+// to w.  Close it to finish; that does not close w.
+func (c Compression) Writer(w io.Writer) (io.WriteCloser, error) {
+	switch c {
+	case Uncompressed:
+		return nopCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupported, c)
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+// OpenText opens the file at path, decompressing it if need be.
+func OpenText(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	c, r, err := DetectCompression(path, f)
+	if err == nil && c == Uncompressed {
+		return f, nil
+	}
+	var zr io.ReadCloser
+	if err == nil {
+		zr, err = c.Reader(r)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return readCloser{zr, f}, nil
+}
+
+// readCloser reads from a decompressor, and closes it and the
+// file under it.
+type readCloser struct {
+	io.ReadCloser
+	f *os.File
+}
+
+func (rc readCloser) Close() error {
+	err := rc.ReadCloser.Close()
+	if ferr := rc.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package textfile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectCompression(t *testing.T) {
+	for _, tc := range []struct {
+		name, in string
+		want     Compression
+	}{
+		{"", "", Uncompressed},
+		{"", "foo\n", Uncompressed},
+		{"", "\x1f\x8b\x08\x00", Gzip},
+		{"", "\x28\xb5\x2f\xfd\x00", Zstd},
+		{"", "\x1f", Uncompressed},
+		{"a.log.gz", "foo\n", Gzip},
+		{"a.log.zst", "foo\n", Zstd},
+		{"a.gz.log", "foo\n", Uncompressed},
+	} {
+		c, r, err := DetectCompression(tc.name, strings.NewReader(tc.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		all, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != tc.want || string(all) != tc.in {
+			t.Errorf("DetectCompression(%q, %q) = %s, %q; want %s, %q", tc.name, tc.in, c, all, tc.want, tc.in)
+		}
+	}
+}
+
+func TestCompressionRoundTrip(t *testing.T) {
+	const text = "foo\r\nbar\r\n"
+	for _, c := range []Compression{Uncompressed, Gzip} {
+		buf := &bytes.Buffer{}
+		w, err := c.Writer(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(w, text)
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		got, r, err := DetectCompression("", buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c {
+			t.Errorf("%s output detected as %s", c, got)
+		}
+		zr, err := got.Reader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b, err := io.ReadAll(zr); string(b) != text || err != nil {
+			t.Errorf("%s round trip = %q, %v; want %q", c, b, err, text)
+		}
+	}
+
+	if _, err := Zstd.Reader(strings.NewReader("")); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Zstd.Reader got error %v; want %v", err, ErrUnsupported)
+	}
+}
+
+func TestOpenText(t *testing.T) {
+	const text = "foo\r\n"
+	path := filepath.Join(t.TempDir(), "a.log.gz")
+	buf := &bytes.Buffer{}
+	w, _ := Gzip.Writer(buf)
+	io.WriteString(w, text)
+	w.Close()
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := OpenText(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != text {
+		t.Errorf("OpenText(%s) read %q; want %q", filepath.Base(path), b, text)
+	}
+
+	info, err := ScanFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.CRLF != 1 || info.Binary {
+		t.Errorf("ScanFile(%s) = %+v; want 1 CRLF, not binary", filepath.Base(path), info)
+	}
+}
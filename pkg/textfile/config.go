@@ -0,0 +1,305 @@
+package textfile
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Rule is what a file's .gitattributes and .editorconfig say
+// about its line endings.
+type Rule struct {
+	// Skip is true if .gitattributes marks the file -text or
+	// binary, so it shouldn't be converted.
+	Skip bool
+
+	// EOL is the line ending the file should have, lf, crlf or
+	// cr, or "" if no config says.  An eol attribute in
+	// .gitattributes wins over end_of_line in .editorconfig.
+	EOL string
+}
+
+// Rules finds the Rule for files, as git and EditorConfig would.
+// For each file it searches up from the file's directory, like
+// searchup, for .gitattributes files as far as the top of the git
+// repository, and for .editorconfig files as far as one marked
+// root.  Settings closer to the file win.  Config files are read
+// once, and kept for the next file.  A Rules is safe for
+// concurrent use.
+type Rules struct {
+	mu      sync.Mutex
+	configs map[string][]section // by config file path; nil if absent
+}
+
+// NewRules returns an empty Rules.
+func NewRules() *Rules {
+	return &Rules{configs: make(map[string][]section)}
+}
+
+// section is the settings for files that match a pattern: a line
+// of .gitattributes, or a section of .editorconfig.
+type section struct {
+	dir       string         // the config file's directory
+	match     *regexp.Regexp // nil matches nothing
+	baseMatch bool           // match the base name, not the path below dir
+	props     map[string]string
+	root      bool // .editorconfig's root = true, on the first section
+}
+
+// For returns the Rule for the file at path.
+func (r *Rules) For(path string) (Rule, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	var attrs, editor [][]section // closest first
+	gitDone, editorDone := false, false
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if !gitDone {
+			secs, err := r.load(filepath.Join(dir, ".gitattributes"), parseGitattributes)
+			if err != nil {
+				return Rule{}, err
+			}
+			attrs = append(attrs, secs)
+			if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+				gitDone = true
+			}
+		}
+		if !editorDone {
+			secs, err := r.load(filepath.Join(dir, ".editorconfig"), parseEditorconfig)
+			if err != nil {
+				return Rule{}, err
+			}
+			editor = append(editor, secs)
+			editorDone = len(secs) > 0 && secs[0].root
+		}
+		if dir == filepath.Dir(dir) || gitDone && editorDone {
+			break
+		}
+	}
+
+	var rule Rule
+	text, eol := apply(path, attrs, "text"), apply(path, attrs, "eol")
+	switch {
+	case text == "unset":
+		rule.Skip = true
+	case eol == "lf" || eol == "crlf":
+		rule.EOL = eol
+	default:
+		switch e := strings.ToLower(apply(path, editor, "end_of_line")); e {
+		case "lf", "crlf", "cr":
+			rule.EOL = e
+		}
+	}
+	return rule, nil
+}
+
+// apply returns the last value of prop set by a section that
+// matches path, working from the farthest config file to the
+// closest.  It returns "" if none sets prop.
+func apply(path string, configs [][]section, prop string) string {
+	var v string
+	for i := len(configs) - 1; i >= 0; i-- {
+		for _, sec := range configs[i] {
+			val, ok := sec.props[prop]
+			if !ok || !sec.matches(path) {
+				continue
+			}
+			v = val
+		}
+	}
+	return v
+}
+
+func (sec section) matches(path string) bool {
+	switch {
+	case sec.match == nil:
+		return false
+	case sec.baseMatch:
+		return sec.match.MatchString(filepath.Base(path))
+	}
+
+	rel, err := filepath.Rel(sec.dir, path)
+	if err != nil {
+		return false
+	}
+	return sec.match.MatchString(filepath.ToSlash(rel))
+}
+
+// load returns the sections of the config file at path, parsing
+// it the first time.  A missing file has none.
+func (r *Rules) load(path string, parse func(dir string, f *os.File) ([]section, error)) ([]section, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if secs, ok := r.configs[path]; ok {
+		return secs, nil
+	}
+
+	f, err := os.Open(path)
+	switch {
+	case os.IsNotExist(err):
+		r.configs[path] = nil
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	defer f.Close()
+
+	secs, err := parse(filepath.Dir(path), f)
+	if err != nil {
+		return nil, err
+	}
+	r.configs[path] = secs
+	return secs, nil
+}
+
+// parseGitattributes reads lines of "pattern attr...".  Only text
+// and eol are kept: text is "set", "unset" or "auto", and binary
+// unsets it.  "!attr" returns attr to unspecified, which is "".
+func parseGitattributes(dir string, f *os.File) ([]section, error) {
+	var secs []section
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], "[attr]") {
+			continue
+		}
+		pat := fields[0]
+		if strings.HasSuffix(pat, "/") {
+			continue // only matches directories
+		}
+
+		props := make(map[string]string)
+		for _, a := range fields[1:] {
+			switch {
+			case a == "text":
+				props["text"] = "set"
+			case a == "-text" || a == "binary":
+				props["text"] = "unset"
+			case a == "text=auto":
+				props["text"] = "auto"
+			case a == "!text":
+				props["text"] = ""
+			case strings.HasPrefix(a, "eol="):
+				props["eol"] = strings.TrimPrefix(a, "eol=")
+			case a == "-eol" || a == "!eol":
+				props["eol"] = ""
+			}
+		}
+		if len(props) == 0 {
+			continue
+		}
+		secs = append(secs, newSection(dir, pat, props))
+	}
+	return secs, sc.Err()
+}
+
+// parseEditorconfig reads [pattern] sections of "key = value"
+// lines.  Keys before the first section go into a section that
+// matches nothing, where root = true marks the last file to read.
+func parseEditorconfig(dir string, f *os.File) ([]section, error) {
+	secs := []section{{props: map[string]string{}}}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case line[0] == '[' && line[len(line)-1] == ']':
+			secs = append(secs, newSection(dir, line[1:len(line)-1], map[string]string{}))
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		k, v = strings.ToLower(strings.TrimSpace(k)), strings.TrimSpace(v)
+		secs[len(secs)-1].props[k] = v
+	}
+	secs[0].root = strings.EqualFold(secs[0].props["root"], "true")
+	return secs, sc.Err()
+}
+
+// newSection makes a section for pat, relative to dir.  A pattern
+// without a slash matches a file's base name, at any depth;
+// otherwise it matches the path below dir.
+func newSection(dir, pat string, props map[string]string) section {
+	sec := section{dir: dir, props: props}
+	sec.baseMatch = !strings.Contains(pat, "/")
+	sec.match = globRegexp(strings.TrimPrefix(pat, "/"))
+	return sec
+}
+
+// globRegexp compiles a glob with *, which doesn't match a slash,
+// **, which does, ?, [...] and {a,b}.  It returns nil for a bad
+// glob.
+func globRegexp(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				b.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				b.WriteString(".*")
+				i++
+			default:
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			j := strings.IndexByte(glob[i:], ']')
+			if j < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += j
+		case '{':
+			b.WriteString("(?:")
+			braces++
+		case '}':
+			if braces == 0 {
+				b.WriteString(`\}`)
+				continue
+			}
+			b.WriteString(")")
+			braces--
+		case ',':
+			if braces == 0 {
+				b.WriteString(",")
+				continue
+			}
+			b.WriteString("|")
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil
+	}
+	return re
+}
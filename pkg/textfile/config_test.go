@@ -0,0 +1,92 @@
+package textfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRulesFor(t *testing.T) {
+	dir := t.TempDir()
+	for name, s := range map[string]string{
+		"repo/.git/HEAD": "",
+		"repo/.gitattributes": `# comment
+* text=auto
+*.bat eol=crlf
+*.png binary
+docs/** eol=lf
+`,
+		"repo/sub/.gitattributes": "*.bat -text\n",
+		".editorconfig": `root = true
+
+[*]
+end_of_line = cr
+`,
+		"repo/.editorconfig": `[*.{md,txt}]
+end_of_line = CRLF
+
+[Makefile]
+end_of_line = lf
+`,
+		"other/.gitattributes": "*.txt eol=crlf\n", // not in repo's tree
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules := NewRules()
+	for _, tc := range []struct {
+		path string
+		want Rule
+	}{
+		{"repo/run.bat", Rule{EOL: "crlf"}},
+		{"repo/a/b/run.bat", Rule{EOL: "crlf"}},
+		{"repo/sub/run.bat", Rule{Skip: true}},
+		{"repo/logo.png", Rule{Skip: true}},
+		{"repo/docs/a/b.bat", Rule{EOL: "lf"}},
+		{"repo/README.md", Rule{EOL: "crlf"}},
+		{"repo/x/notes.txt", Rule{EOL: "crlf"}},
+		{"repo/Makefile", Rule{EOL: "lf"}},
+		{"repo/main.go", Rule{EOL: "cr"}},
+		{"outside.go", Rule{EOL: "cr"}},
+	} {
+		got, err := rules.For(filepath.Join(dir, tc.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("For(%s) = %+v; want %+v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	for _, tc := range []struct {
+		glob, name string
+		want       bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"**/*.go", "main.go", true},
+		{"**/*.go", "a/b/main.go", true},
+		{"docs/**", "docs/a/b.md", true},
+		{"a/**/b", "a/x/y/b", true},
+		{"a/**/b", "a/b", true},
+		{"?.c", "x.c", true},
+		{"[!a]*", "abc", false},
+		{"[a-c]*", "bcd", true},
+		{"*.{md,txt}", "a.txt", true},
+		{"*.{md,txt}", "a.go", false},
+		{"a+b(1).txt", "a+b(1).txt", true},
+	} {
+		re := globRegexp(tc.glob)
+		if got := re != nil && re.MatchString(tc.name); got != tc.want {
+			t.Errorf("glob %s on %s = %t; want %t", tc.glob, tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package textfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// diffContext is how many unchanged lines surround each hunk.
+const diffContext = 3
+
+// maxHunk caps the changed lines a hunk holds before it's written
+// out, and a new one started, so a file that changes on every
+// line streams in pieces and isn't held in memory.
+const maxHunk = 1000
+
+// Diff writes a unified diff, under the name name, of old and
+// new: a text and its conversion.  It reports whether they differ.
+// Line endings are shown as \r\n, \n and \r at the end of each
+// line, and a BOM as \ufeff, so a diff of only those can be seen.
+//
+// Diff reads old and new a line at a time, and doesn't look for
+// the shortest diff.  It pairs each line of old with the next of
+// new if they differ only in line ending, trailing spaces and tabs,
+// or a BOM, as conversion does, and otherwise counts an old blank
+// line as removed.  A changed line is shown removed, then added,
+// in place, not grouped with its neighbors.  UTF-16 old, told by
+// its BOM, is read by code unit; new is read the same way, BOM or
+// not.
+func Diff(w io.Writer, name string, old, new io.Reader) (changed bool, err error) {
+	enc, old, err := ReadBOM(old)
+	if err != nil {
+		return false, err
+	}
+	old = io.MultiReader(bytes.NewReader(enc.BOM()), old)
+	order := enc.ByteOrder()
+
+	d := &differ{w: w, name: name, order: order}
+	or := newLineReader(old, order)
+	nr := newLineReader(new, order)
+
+	o, oerr := or.next()
+	n, nerr := nr.next()
+	for (oerr == nil || nerr == nil) && d.err == nil {
+		switch {
+		case oerr != nil && oerr != io.EOF:
+			return d.changed, oerr
+		case nerr != nil && nerr != io.EOF:
+			return d.changed, nerr
+		case oerr == io.EOF:
+			d.add('+', n)
+			n, nerr = nr.next()
+		case nerr == io.EOF:
+			d.add('-', o)
+			o, oerr = or.next()
+		case bytes.Equal(o.raw, n.raw):
+			d.same(o)
+			o, oerr = or.next()
+			n, nerr = nr.next()
+		case o.key(order) != n.key(order) && o.key(order) == "":
+			d.add('-', o)
+			o, oerr = or.next()
+		default:
+			d.add('-', o)
+			d.add('+', n)
+			o, oerr = or.next()
+			n, nerr = nr.next()
+		}
+	}
+	d.flush(true)
+	return d.changed, d.err
+}
+
+// line is a line of text with its line ending, if any.
+type line struct {
+	raw   []byte
+	end   int  // where the line ending starts in raw
+	first bool // the first line, which may start with a BOM
+}
+
+// key is the line without what conversion changes.
+func (l line) key(order binary.ByteOrder) string {
+	text := l.raw[:l.end]
+	bom := UTF8.BOM()
+	if order != nil {
+		bom = make([]byte, 2)
+		order.PutUint16(bom, 0xfeff)
+	}
+	if l.first {
+		text = bytes.TrimPrefix(text, bom)
+	}
+	w := 1
+	if order != nil {
+		w = 2
+	}
+	for len(text) >= w {
+		c := text[len(text)-w:]
+		if unit(c, order) != ' ' && unit(c, order) != '\t' {
+			break
+		}
+		text = text[:len(text)-w]
+	}
+	return string(text)
+}
+
+func unit(b []byte, order binary.ByteOrder) uint16 {
+	if order == nil {
+		return uint16(b[0])
+	}
+	return order.Uint16(b)
+}
+
+// render shows l for a diff, with its line ending and any BOM
+// made visible.
+func (l line) render(order binary.ByteOrder) string {
+	text, end := l.raw[:l.end], l.raw[l.end:]
+
+	var s string
+	switch order {
+	case nil:
+		s = string(text)
+	default:
+		units := make([]uint16, len(text)/2)
+		for i := range units {
+			units[i] = order.Uint16(text[2*i:])
+		}
+		s = string(utf16.Decode(units))
+		if len(text)%2 == 1 {
+			s += fmt.Sprintf("\\x%02x", text[len(text)-1])
+		}
+	}
+	s = strings.ReplaceAll(s, "\ufeff", `\ufeff`)
+
+	w := len(end)
+	if order != nil {
+		w /= 2
+	}
+	switch {
+	case w == 2:
+		s += `\r\n`
+	case w == 1 && unit(end, order) == '\n':
+		s += `\n`
+	case w == 1:
+		s += `\r`
+	}
+	return s
+}
+
+// lineReader splits text into lines, ending at LF, CRLF or a bare
+// CR, by code unit.
+type lineReader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+	w     int // code unit width
+	n     int // lines read
+}
+
+func newLineReader(r io.Reader, order binary.ByteOrder) *lineReader {
+	lr := &lineReader{r: bufio.NewReader(r), order: order, w: 1}
+	if order != nil {
+		lr.w = 2
+	}
+	return lr
+}
+
+// next returns the next line, or io.EOF if there are no more.
+func (lr *lineReader) next() (line, error) {
+	l := line{first: lr.n == 0}
+	u := make([]byte, lr.w)
+	for {
+		n, err := io.ReadFull(lr.r, u)
+		l.raw = append(l.raw, u[:n]...)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			if len(l.raw) == 0 {
+				return l, io.EOF
+			}
+			l.end = len(l.raw)
+			lr.n++
+			return l, nil
+		}
+		if err != nil {
+			return l, err
+		}
+
+		switch unit(u, lr.order) {
+		case '\n':
+			l.end = len(l.raw) - lr.w
+			lr.n++
+			return l, nil
+		case '\r':
+			l.end = len(l.raw) - lr.w
+			if next, err := lr.r.Peek(lr.w); err == nil && unit(next, lr.order) == '\n' {
+				l.raw = append(l.raw, next...)
+				lr.r.Discard(lr.w)
+			}
+			lr.n++
+			return l, nil
+		}
+	}
+}
+
+// differ builds the hunks of a diff and writes them out.
+type differ struct {
+	w       io.Writer
+	name    string
+	order   binary.ByteOrder
+	changed bool
+	err     error // from w
+
+	oldLine, newLine int      // lines of each read so far
+	before           []string // up to diffContext unchanged lines before a hunk
+
+	hunk               []string // nil if not in a hunk
+	oldStart, newStart int
+	oldCount, newCount int
+	changes, after     int // changed lines in hunk; unchanged lines since the last
+}
+
+// same takes a line that's unchanged.
+func (d *differ) same(l line) {
+	d.oldLine++
+	d.newLine++
+	s := " " + l.render(d.order)
+	if d.hunk == nil {
+		d.before = append(d.before, s)
+		if len(d.before) > diffContext {
+			d.before = d.before[1:]
+		}
+		return
+	}
+
+	d.hunk = append(d.hunk, s)
+	d.oldCount++
+	d.newCount++
+	d.after++
+	if d.after == 2*diffContext {
+		// too far to the next change to join it; keep the last
+		// lines as context for the next hunk
+		tail := d.hunk[len(d.hunk)-diffContext:]
+		d.hunk = d.hunk[:len(d.hunk)-diffContext]
+		d.oldCount -= diffContext
+		d.newCount -= diffContext
+		d.flush(false)
+		d.before = append(d.before[:0], tail...)
+	}
+}
+
+// add takes a line removed from old, op '-', or added in new, '+'.
+func (d *differ) add(op byte, l line) {
+	if d.hunk == nil {
+		d.hunk = append([]string{}, d.before...)
+		d.oldStart, d.newStart = d.oldLine-len(d.before)+1, d.newLine-len(d.before)+1
+		d.oldCount, d.newCount = len(d.before), len(d.before)
+		d.before = d.before[:0]
+	}
+
+	s := string(op) + l.render(d.order)
+	if l.end == len(l.raw) {
+		s += "\n\\ No newline at end of file"
+	}
+	d.hunk = append(d.hunk, s)
+	switch op {
+	case '-':
+		d.oldLine++
+		d.oldCount++
+	case '+':
+		d.newLine++
+		d.newCount++
+	}
+	d.changes++
+	d.after = 0
+
+	if d.changes >= maxHunk {
+		d.flush(false)
+	}
+}
+
+// flush writes out the hunk, if any, trimming its trailing context
+// at the end of the text.
+func (d *differ) flush(atEOF bool) {
+	if d.hunk == nil || d.err != nil {
+		return
+	}
+	if atEOF && d.after > diffContext {
+		cut := d.after - diffContext
+		d.hunk = d.hunk[:len(d.hunk)-cut]
+		d.oldCount -= cut
+		d.newCount -= cut
+	}
+
+	var b strings.Builder
+	if !d.changed {
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n", d.name, d.name)
+		d.changed = true
+	}
+	fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(d.oldStart, d.oldCount), hunkRange(d.newStart, d.newCount))
+	for _, s := range d.hunk {
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+	d.hunk, d.changes, d.after = nil, 0, 0
+
+	_, d.err = io.WriteString(d.w, b.String())
+}
+
+// hunkRange formats a hunk's start and length.  An empty range
+// starts at the line before it.
+func hunkRange(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start-1)
+	case 1:
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
@@ -0,0 +1,125 @@
+package textfile
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"same", "a\nb\n", "a\nb\n", ""},
+		{
+			"endings", "a\r\nb\nc\r\n", "a\nb\nc\n",
+			`--- f
++++ f
+@@ -1,3 +1,3 @@
+-a\r\n
++a\n
+ b\n
+-c\r\n
++c\n
+`,
+		},
+		{
+			"final newline", "a\nb", "a\nb\n",
+			`--- f
++++ f
+@@ -1,2 +1,2 @@
+ a\n
+-b
+\ No newline at end of file
++b\n
+`,
+		},
+		{
+			"squeezed", "a\n\n\n\n", "a\n\n",
+			`--- f
++++ f
+@@ -1,4 +1,2 @@
+ a\n
+ \n
+-\n
+-\n
+`,
+		},
+		{
+			"merged CR", "a\r\r\nb\n", "a\r\nb\n",
+			`--- f
++++ f
+@@ -1,3 +1,2 @@
+-a\r
++a\r\n
+-\r\n
+ b\n
+`,
+		},
+		{
+			"BOM", "\xef\xbb\xbfa\n", "a\n",
+			`--- f
++++ f
+@@ -1 +1 @@
+-\ufeffa\n
++a\n
+`,
+		},
+		{
+			"UTF-16", "\xff\xfea\x00\r\x00\n\x00", "\xff\xfea\x00\n\x00",
+			`--- f
++++ f
+@@ -1 +1 @@
+-\ufeffa\r\n
++\ufeffa\n
+`,
+		},
+	} {
+		buf := &bytes.Buffer{}
+		changed, err := Diff(buf, "f", strings.NewReader(tc.old), strings.NewReader(tc.new))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tc.want || changed != (tc.want != "") {
+			t.Errorf("%s: changed %t\n got\n%s\n want\n%s", tc.name, changed, got, tc.want)
+		}
+	}
+}
+
+// TestDiffHunks changes lines far enough apart for separate hunks,
+// and more lines in a row than fit in one.
+func TestDiffHunks(t *testing.T) {
+	var old, new strings.Builder
+	for i := 1; i <= 20+maxHunk; i++ {
+		fmt.Fprintf(&old, "%d\n", i)
+		switch {
+		case i == 2 || i == 12 || i > 20:
+			fmt.Fprintf(&new, "%d\r\n", i)
+		default:
+			fmt.Fprintf(&new, "%d\n", i)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := Diff(buf, "f", strings.NewReader(old.String()), strings.NewReader(new.String())); err != nil {
+		t.Fatal(err)
+	}
+	var headers []string
+	for _, l := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(l, "@@") {
+			headers = append(headers, l)
+		}
+	}
+	want := []string{
+		"@@ -1,5 +1,5 @@",
+		"@@ -9,7 +9,7 @@",
+		"@@ -18,503 +18,503 @@",
+		"@@ -521,500 +521,500 @@",
+	}
+	if strings.Join(headers, "\n") != strings.Join(want, "\n") {
+		t.Errorf("hunks\n%s\nwant\n%s", strings.Join(headers, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,195 @@
+package textfile
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// Info describes the line endings of a file.
+type Info struct {
+	Name     string   `json:"name"`
+	Encoding Encoding `json:"encoding"`
+	BOM      bool     `json:"bom"`
+	Binary   bool     `json:"binary"`
+
+	// Lines counts the lines, including a last one without a
+	// line ending.
+	Lines int `json:"lines"`
+
+	CRLF int `json:"crlf"`
+	LF   int `json:"lf"` // bare LFs, not part of a CRLF
+	CR   int `json:"cr"` // bare CRs, not part of a CRLF
+
+	// The line numbers, counting from 1, of the first line to
+	// end with each kind of line ending; 0 if none does.
+	FirstCRLF int `json:"firstCRLF"`
+	FirstLF   int `json:"firstLF"`
+	FirstCR   int `json:"firstCR"`
+
+	// FinalNewline is true if the last line ends with a line
+	// ending.  An empty file has no last line, so it's false.
+	FinalNewline bool `json:"finalNewline"`
+
+	// TrailingSpace counts the lines that end in a space or a
+	// tab, and FirstTrailingSpace is the first of them.
+	TrailingSpace      int `json:"trailingSpace"`
+	FirstTrailingSpace int `json:"firstTrailingSpace"`
+
+	// TrailingBlank counts the blank lines at the end of the
+	// file.
+	TrailingBlank int `json:"trailingBlank"`
+}
+
+// Scan reads all of r and counts its line endings, by 2-byte code
+// unit for UTF-16.  Name is left for the caller to fill in.
+func Scan(r io.Reader) (Info, error) {
+	enc, r, err := ReadBOM(r)
+	if err != nil {
+		return Info{}, err
+	}
+	info := Info{Encoding: enc, BOM: enc != Plain}
+
+	br := bufio.NewReaderSize(r, sniffLen)
+	first, err := br.Peek(sniffLen)
+	if err != nil && err != io.EOF {
+		return Info{}, err
+	}
+	order := enc.ByteOrder()
+	switch order {
+	case nil:
+		info.Binary = IsBinary(first)
+	default:
+		info.Binary = isBinaryUTF16(first, order)
+	}
+
+	var pair [2]byte
+	next := func() (uint16, error) {
+		b, err := br.ReadByte()
+		if err != nil || order == nil {
+			return uint16(b), err
+		}
+		pair[0] = b
+		if pair[1], err = br.ReadByte(); err != nil {
+			return 0, err // ignore an odd byte at the end
+		}
+		return order.Uint16(pair[:]), nil
+	}
+
+	var (
+		line  = 1
+		space bool // the line so far ends in a space or tab
+		empty = true
+		blank int // blank lines in a row
+	)
+	// endLine notes how the line just ended did
+	endLine := func() {
+		if space {
+			info.TrailingSpace++
+			if info.FirstTrailingSpace == 0 {
+				info.FirstTrailingSpace = line
+			}
+		}
+		switch {
+		case empty:
+			blank++
+		default:
+			blank = 0
+		}
+		space, empty = false, true
+	}
+	// count tallies a line ending and notes the first line it ends
+	count := func(n, first *int) {
+		endLine()
+		*n++
+		if *first == 0 {
+			*first = line
+		}
+		line++
+	}
+
+	var prev uint16
+	for {
+		cur, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Info{}, err
+		}
+
+		switch {
+		case cur == '\n' && prev == '\r':
+			count(&info.CRLF, &info.FirstCRLF)
+		case cur == '\n':
+			count(&info.LF, &info.FirstLF)
+		case prev == '\r':
+			count(&info.CR, &info.FirstCR)
+		}
+		if cur != '\r' && cur != '\n' {
+			space, empty = cur == ' ' || cur == '\t', false
+		}
+		prev = cur
+	}
+	if prev == '\r' {
+		count(&info.CR, &info.FirstCR)
+	}
+	info.FinalNewline = prev == '\n' || prev == '\r'
+	info.Lines = line - 1
+	if !empty {
+		endLine()
+		info.Lines++
+	}
+	info.TrailingBlank = blank
+
+	return info, nil
+}
+
+// ScanFile is Scan for the file at path, decompressed if need be,
+// with Name set to path.
+func ScanFile(path string) (Info, error) {
+	f, err := OpenText(path)
+	if err != nil {
+		return Info{}, err
+	}
+	defer f.Close()
+
+	info, err := Scan(f)
+	if err != nil {
+		return Info{}, fmt.Errorf("%s: %w", path, err)
+	}
+	info.Name = path
+	return info, nil
+}
+
+// WriteInfoTable writes infos to w as a table, one row per file.
+func WriteInfoTable(w io.Writer, infos []Info) error {
+	yesNo := map[bool]string{true: "yes", false: "no"}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CRLF\tLF\tCR\tBOM\tBINARY\tFINAL NL\tFILE")
+	for _, info := range infos {
+		bom := "-"
+		if info.BOM {
+			bom = info.Encoding.String()
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			info.CRLF, info.LF, info.CR, bom, yesNo[info.Binary], yesNo[info.FinalNewline], info.Name)
+	}
+	return tw.Flush()
+}
+
+// WriteInfoJSON writes infos to w as a JSON array.
+func WriteInfoJSON(w io.Writer, infos []Info) error {
+	if infos == nil {
+		infos = []Info{}
+	}
+	b, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
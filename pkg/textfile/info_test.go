@@ -0,0 +1,82 @@
+package textfile
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Info
+	}{
+		{"", Info{}},
+		{"foo", Info{Lines: 1}},
+		{"foo\n", Info{Lines: 1, LF: 1, FirstLF: 1, FinalNewline: true}},
+		{"a\r\nb\nc\rd", Info{Lines: 4, CRLF: 1, LF: 1, CR: 1, FirstCRLF: 1, FirstLF: 2, FirstCR: 3}},
+		{"a\r\r\n\n\r", Info{Lines: 4, CRLF: 1, LF: 1, CR: 2, FirstCRLF: 2, FirstLF: 3, FirstCR: 1, FinalNewline: true, TrailingBlank: 3}},
+		{"\xef\xbb\xbfa\r\n", Info{Lines: 1, Encoding: UTF8, BOM: true, CRLF: 1, FirstCRLF: 1, FinalNewline: true}},
+		{"\xff\xfea\x00\r\x00\n\x00\n\x00", Info{Lines: 2, Encoding: UTF16LE, BOM: true, CRLF: 1, LF: 1, FirstCRLF: 1, FirstLF: 2, FinalNewline: true, TrailingBlank: 1}},
+		{"\xfe\xff\x00a\x00\r\x00b", Info{Lines: 2, Encoding: UTF16BE, BOM: true, CR: 1, FirstCR: 1}},
+		{"\xfe\xff\x0a\x00", Info{Lines: 1, Encoding: UTF16BE, BOM: true}}, // U+0A00, not an LF
+		{"a \nb\t\n\n\n", Info{Lines: 4, LF: 4, FirstLF: 1, FinalNewline: true, TrailingSpace: 2, FirstTrailingSpace: 1, TrailingBlank: 2}},
+		{"a\n\nb  ", Info{Lines: 3, LF: 2, FirstLF: 1, TrailingSpace: 1, FirstTrailingSpace: 3}},
+		{"a\x00\n", Info{Lines: 1, Binary: true, LF: 1, FirstLF: 1, FinalNewline: true}},
+	} {
+		got, err := Scan(strings.NewReader(tc.in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("Scan(%q)\n  got %+v\n want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestWriteInfo(t *testing.T) {
+	infos := []Info{
+		{Name: "a.txt", CRLF: 2, FinalNewline: true},
+		{Name: "b.txt", Encoding: UTF16LE, BOM: true, LF: 10, CR: 1},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteInfoTable(buf, infos); err != nil {
+		t.Fatal(err)
+	}
+	want := `CRLF  LF  CR  BOM       BINARY  FINAL NL  FILE
+2     0   0   -         no      yes       a.txt
+0     10  1   UTF-16LE  no      no        b.txt
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteInfoTable()\n  got\n%s\n want\n%s", got, want)
+	}
+
+	buf.Reset()
+	if err := WriteInfoJSON(buf, infos[1:]); err != nil {
+		t.Fatal(err)
+	}
+	want = `[
+  {
+    "name": "b.txt",
+    "encoding": "UTF-16LE",
+    "bom": true,
+    "binary": false,
+    "lines": 0,
+    "crlf": 0,
+    "lf": 10,
+    "cr": 1,
+    "firstCRLF": 0,
+    "firstLF": 0,
+    "firstCR": 0,
+    "finalNewline": false,
+    "trailingSpace": 0,
+    "firstTrailingSpace": 0,
+    "trailingBlank": 0
+  }
+]
+`
+	if got := buf.String(); got != want {
+		t.Errorf("WriteInfoJSON()\n  got\n%s\n want\n%s", got, want)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package textfile
+
+import "os"
+
+// keepOwner does nothing where files have no Unix owner.
+func keepOwner(path string, fi os.FileInfo) error { return nil }
+
+// links always reports a single link where it can't tell.
+func links(fi os.FileInfo) uint64 { return 1 }
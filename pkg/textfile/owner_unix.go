@@ -0,0 +1,36 @@
+//go:build unix
+
+package textfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// keepOwner gives the file at path the owner and group of fi.
+// Only root may give a file away, so failing that it settles for
+// the group, and failing that too, for the defaults.
+func keepOwner(path string, fi os.FileInfo) error {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	err := os.Lchown(path, int(st.Uid), int(st.Gid))
+	if errors.Is(err, fs.ErrPermission) {
+		err = os.Lchown(path, -1, int(st.Gid))
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		err = nil
+	}
+	return err
+}
+
+// links returns the number of hard links to the file fi describes.
+func links(fi os.FileInfo) uint64 {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink)
+	}
+	return 1
+}
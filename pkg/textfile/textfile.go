@@ -0,0 +1,324 @@
+// Package textfile applies a stream conversion, like dos2unix,
+// to files on disk.
+//
+// Rewrite converts a file in place: the converted copy is
+// written to a temp file in the same directory, then renamed
+// over the original, so readers see either the old file or the
+// new one, never a partial write.  The exception is a file with
+// more than one hard link, when asked to write through to it.
+package textfile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// keptMode is what a rewritten file keeps of fi's mode: its
+// permissions, and its setuid, setgid and sticky bits.
+func keptMode(fi fs.FileInfo) fs.FileMode {
+	return fi.Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+}
+
+// ErrSymlink is returned by Rewrite for a symlink it was not
+// asked to follow.
+var ErrSymlink = errors.New("is a symlink")
+
+// Converter reads all of in and writes its conversion to out.
+type Converter func(in io.Reader, out io.Writer) error
+
+// Options control how Rewrite replaces a file.
+type Options struct {
+	// KeepMtime gives the converted file the original's
+	// modification time.
+	KeepMtime bool
+
+	// Backup, if set, is a suffix: a file that changes is first
+	// copied to its path plus Backup, replacing any file there.
+	Backup string
+
+	// FollowSymlinks rewrites the file a symlink points to, and
+	// leaves the link alone.  Any backup is made next to that
+	// file.  Without it, a symlink is refused with ErrSymlink.
+	FollowSymlinks bool
+
+	// WriteThrough writes the conversion back into a file with
+	// more than one hard link, so every name for it sees the
+	// change.  Without it, the file is replaced, which breaks the
+	// link: the other names keep the original.  Writing through
+	// is not atomic; if it fails partway, only a backup has the
+	// original.
+	WriteThrough bool
+
+	// Unchanged, if set, reports whether the Converter would
+	// leave the file read from r as it is.  If so, Rewrite
+	// writes nothing, not even a temp file.  It should be
+	// cheaper than converting, e.g., by stopping at the first
+	// thing to change.
+	Unchanged func(r io.Reader) (bool, error)
+}
+
+// Rewrite converts the file at path with conv.  The file is
+// only replaced if conv changed its contents; changed reports
+// whether it was.  The replacement keeps the original's
+// permissions, and its owner and group where permitted.  If
+// Rewrite fails, the file is left as it was, and no backup of it
+// is made.
+//
+// Only regular files are rewritten: FIFOs, devices, sockets and
+// directories are refused, and so are symlinks, unless
+// opts.FollowSymlinks.
+func Rewrite(path string, conv Converter, opts Options) (changed bool, err error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return false, err
+	}
+	if fi.Mode()&fs.ModeSymlink != 0 {
+		if !opts.FollowSymlinks {
+			return false, fmt.Errorf("%s: %w", path, ErrSymlink)
+		}
+		if path, err = filepath.EvalSymlinks(path); err != nil {
+			return false, err
+		}
+		if fi, err = os.Lstat(path); err != nil {
+			return false, err
+		}
+	}
+	if !fi.Mode().IsRegular() {
+		return false, fmt.Errorf("%s: not a regular file", path)
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+
+	// path may have been replaced since the Lstat
+	if opened, err := src.Stat(); err != nil {
+		return false, err
+	} else if !os.SameFile(fi, opened) {
+		return false, fmt.Errorf("%s: changed while converting", path)
+	}
+
+	if opts.Unchanged != nil {
+		same, err := opts.Unchanged(src)
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", path, err)
+		}
+		if same {
+			return false, nil
+		}
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if !changed {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err = conv(src, tmp); err != nil {
+		tmp.Close()
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err = tmp.Close(); err != nil {
+		return false, err
+	}
+
+	same, err := sameContents(src, tmp.Name())
+	if err != nil || same {
+		return false, err
+	}
+
+	if opts.Backup != "" {
+		if err = backup(src, fi, path+opts.Backup); err != nil {
+			return false, err
+		}
+	}
+	if opts.WriteThrough && links(fi) > 1 {
+		defer os.Remove(tmp.Name())
+		if err = writeThrough(tmp.Name(), path); err != nil {
+			return false, err
+		}
+		if opts.KeepMtime {
+			if err = os.Chtimes(path, time.Time{}, fi.ModTime()); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	// chown before chmod, since chown can clear setuid and setgid
+	if err = keepOwner(tmp.Name(), fi); err != nil {
+		return false, err
+	}
+	if err = os.Chmod(tmp.Name(), keptMode(fi)); err != nil {
+		return false, err
+	}
+	if opts.KeepMtime {
+		if err = os.Chtimes(tmp.Name(), time.Time{}, fi.ModTime()); err != nil {
+			return false, err
+		}
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		if opts.Backup != "" {
+			os.Remove(path + opts.Backup)
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// writeThrough copies the file at from over the contents of the
+// file at to, keeping to's inode, and so its links, mode and owner.
+func writeThrough(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err = dst.Sync(); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// backup copies orig, described by fi, to path, by way of a temp
+// file, so path is never left half written.  The copy keeps the
+// original's permissions, owner and modification time.
+func backup(orig *os.File, fi os.FileInfo, path string) (err error) {
+	if _, err := orig.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = io.Copy(tmp, orig); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = keepOwner(tmp.Name(), fi); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), keptMode(fi)); err != nil {
+		return err
+	}
+	if err = os.Chtimes(tmp.Name(), time.Time{}, fi.ModTime()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Restore puts the backup of the file at path, its path plus
+// suffix, back in its place.  The backup is gone afterwards.
+func Restore(path, suffix string) error {
+	fi, err := os.Lstat(path + suffix)
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s: not a regular file", path+suffix)
+	}
+	return os.Rename(path+suffix, path)
+}
+
+// Summary tallies a conversion over many files.
+type Summary struct {
+	Scanned   int // files looked at
+	Converted int // files rewritten
+	Skipped   int // files passed over, e.g., by a Filter
+	Failed    int // files that could not be converted
+}
+
+func (s Summary) String() string {
+	str := fmt.Sprintf("%d scanned, %d converted, %d skipped", s.Scanned, s.Converted, s.Skipped)
+	if s.Failed > 0 {
+		str += fmt.Sprintf(", %d failed", s.Failed)
+	}
+	return str
+}
+
+// sameContents rewinds orig and compares it to the file at path.
+func sameContents(orig *os.File, path string) (bool, error) {
+	fi1, err := orig.Stat()
+	if err != nil {
+		return false, err
+	}
+	fi2, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if fi1.Size() != fi2.Size() {
+		return false, nil
+	}
+
+	if _, err := orig.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	const size = 8192
+	r1, r2 := bufio.NewReaderSize(orig, size), bufio.NewReaderSize(f, size)
+	b1, b2 := make([]byte, size), make([]byte, size)
+	for {
+		n1, err1 := io.ReadFull(r1, b1)
+		n2, err2 := io.ReadFull(r2, b2)
+		if !bytes.Equal(b1[:n1], b2[:n2]) {
+			return false, nil
+		}
+		switch {
+		case err1 == io.EOF || err1 == io.ErrUnexpectedEOF:
+			return err2 == io.EOF || err2 == io.ErrUnexpectedEOF, nil
+		case err1 != nil:
+			return false, err1
+		case err2 != nil:
+			return false, err2
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package textfile
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"zacharysyoung/CLUtils/pkg/temptree"
+)
+
+// upper is a stand-in Converter.
+func upper(in io.Reader, out io.Writer) error {
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, strings.ToUpper(string(b)))
+	return err
+}
+
+func writeFile(t *testing.T, name, s string, mode os.FileMode) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(s), mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRewrite(t *testing.T) {
+	path := writeFile(t, "a.txt", "foo\n", 0751)
+
+	changed, err := Rewrite(path, upper, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Errorf("Rewrite(%q) changed = false; want true", "foo\n")
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "FOO\n"; got != want {
+		t.Errorf("after Rewrite got %q; want %q", got, want)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fi.Mode().Perm(), os.FileMode(0751); got != want {
+		t.Errorf("after Rewrite mode = %v; want %v", got, want)
+	}
+
+	assertOnlyFile(t, path)
+}
+
+func TestRewriteUnchanged(t *testing.T) {
+	path := writeFile(t, "a.txt", "FOO\n", 0644)
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := Rewrite(path, upper, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Errorf("Rewrite(%q) changed = true; want false", "FOO\n")
+	}
+
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Errorf("unchanged file was replaced")
+	}
+
+	assertOnlyFile(t, path)
+}
+
+// TestRewriteUnchangedCheck skips the conversion, and the temp
+// file, when Unchanged says so, and otherwise converts from the
+// start of the file.
+func TestRewriteUnchangedCheck(t *testing.T) {
+	path := writeFile(t, "a.txt", "foo\n", 0644)
+
+	converted := false
+	conv := func(in io.Reader, out io.Writer) error {
+		converted = true
+		return upper(in, out)
+	}
+	same := func(r io.Reader) (bool, error) {
+		io.ReadAll(r)
+		return true, nil
+	}
+	if changed, err := Rewrite(path, conv, Options{Unchanged: same}); changed || err != nil || converted {
+		t.Errorf("Rewrite when Unchanged = %t, %v, converted %t; want false, nil, not converted", changed, err, converted)
+	}
+
+	differs := func(r io.Reader) (bool, error) {
+		io.ReadAll(r)
+		return false, nil
+	}
+	if changed, err := Rewrite(path, conv, Options{Unchanged: differs}); !changed || err != nil {
+		t.Errorf("Rewrite when not Unchanged = %t, %v; want true, nil", changed, err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "FOO\n" {
+		t.Errorf("after Rewrite got %q; want %q", b, "FOO\n")
+	}
+	assertOnlyFile(t, path)
+}
+
+func TestRewriteKeepMtime(t *testing.T) {
+	path := writeFile(t, "a.txt", "foo\n", 0644)
+	mtime := time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Rewrite(path, upper, Options{KeepMtime: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.ModTime().Equal(mtime) {
+		t.Errorf("after Rewrite mtime = %v; want %v", fi.ModTime(), mtime)
+	}
+}
+
+func TestRewriteBackup(t *testing.T) {
+	path := writeFile(t, "a.txt", "foo\n", 0640)
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, time.Time{}, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Rewrite(path, upper, Options{Backup: ".bak"}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path + ".bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), "foo\n"; got != want {
+		t.Errorf("backup holds %q; want %q", got, want)
+	}
+	fi, err := os.Stat(path + ".bak")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0640 || !fi.ModTime().Equal(mtime) {
+		t.Errorf("backup mode, mtime = %v, %v; want %v, %v", fi.Mode().Perm(), fi.ModTime(), os.FileMode(0640), mtime)
+	}
+
+	// unchanged the second time: no new backup
+	if err := os.Remove(path + ".bak"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Rewrite(path, upper, Options{Backup: ".bak"}); err != nil {
+		t.Fatal(err)
+	}
+	assertOnlyFile(t, path)
+
+	if err := os.WriteFile(path+".bak", []byte("foo\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(path, ".bak"); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(path); string(b) != "foo\n" {
+		t.Errorf("after Restore got %q; want %q", b, "foo\n")
+	}
+	assertOnlyFile(t, path)
+
+	if err := Restore(path, ".bak"); err == nil {
+		t.Errorf("Restore without a backup didn't error")
+	}
+}
+
+// TestRewriteFailed leaves the file untouched, without a backup,
+// when the conversion fails.
+func TestRewriteFailed(t *testing.T) {
+	path := writeFile(t, "a.txt", "foo\n", 0644)
+	fail := func(in io.Reader, out io.Writer) error {
+		io.WriteString(out, "FO")
+		return io.ErrUnexpectedEOF
+	}
+	if _, err := Rewrite(path, fail, Options{Backup: ".bak"}); err == nil {
+		t.Errorf("Rewrite didn't pass on the error")
+	}
+	if b, _ := os.ReadFile(path); string(b) != "foo\n" {
+		t.Errorf("after a failed Rewrite got %q; want %q", b, "foo\n")
+	}
+	assertOnlyFile(t, path)
+}
+
+func TestRewriteNotRegular(t *testing.T) {
+	if _, err := Rewrite(t.TempDir(), upper, Options{}); err == nil {
+		t.Errorf("Rewrite of a directory didn't error")
+	}
+}
+
+func TestRewriteSymlink(t *testing.T) {
+	tree, prefix, err := temptree.NewTree(
+		f("a.txt"),
+		temptree.L("link.txt", "a.txt"),
+		temptree.L("dangling.txt", "nowhere.txt"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Remove()
+	target, link := filepath.Join(prefix, "a.txt"), filepath.Join(prefix, "link.txt")
+	if err := os.WriteFile(target, []byte("foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Rewrite(link, upper, Options{}); !errors.Is(err, ErrSymlink) {
+		t.Errorf("Rewrite of a symlink got error %v; want %v", err, ErrSymlink)
+	}
+	if b, _ := os.ReadFile(target); string(b) != "foo\n" {
+		t.Errorf("refused Rewrite of a symlink changed its target to %q", b)
+	}
+
+	changed, err := Rewrite(link, upper, Options{FollowSymlinks: true, Backup: ".bak"})
+	if err != nil || !changed {
+		t.Fatalf("Rewrite following a symlink = %t, %v; want true, nil", changed, err)
+	}
+	if b, _ := os.ReadFile(target); string(b) != "FOO\n" {
+		t.Errorf("after Rewrite following a symlink its target holds %q; want %q", b, "FOO\n")
+	}
+	if fi, err := os.Lstat(link); err != nil || fi.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("Rewrite following a symlink replaced the link")
+	}
+	if _, err := os.Stat(target + ".bak"); err != nil {
+		t.Errorf("backup of a followed symlink isn't next to its target: %v", err)
+	}
+
+	dangling := filepath.Join(prefix, "dangling.txt")
+	if _, err := Rewrite(dangling, upper, Options{FollowSymlinks: true}); err == nil {
+		t.Errorf("Rewrite of a dangling symlink didn't error")
+	}
+}
+
+// assertOnlyFile fails if path's directory holds anything else,
+// like a leftover temp file.
+func assertOnlyFile(t *testing.T, path string) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != filepath.Base(path) {
+			t.Errorf("found %s next to %s", e.Name(), filepath.Base(path))
+		}
+	}
+}
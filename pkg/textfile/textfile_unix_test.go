@@ -0,0 +1,102 @@
+//go:build unix
+
+package textfile
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestRewriteHardlink(t *testing.T) {
+	for _, tc := range []struct {
+		writeThrough bool
+		want         string // in the other link
+	}{
+		{false, "foo\n"},
+		{true, "FOO\n"},
+	} {
+		path := writeFile(t, "a.txt", "foo\n", 0640)
+		other := filepath.Join(filepath.Dir(path), "b.txt")
+		if err := os.Link(path, other); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := Rewrite(path, upper, Options{WriteThrough: tc.writeThrough}); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := os.ReadFile(path); string(b) != "FOO\n" {
+			t.Errorf("WriteThrough=%t: after Rewrite got %q; want %q", tc.writeThrough, b, "FOO\n")
+		}
+		if b, _ := os.ReadFile(other); string(b) != tc.want {
+			t.Errorf("WriteThrough=%t: after Rewrite the other link holds %q; want %q", tc.writeThrough, b, tc.want)
+		}
+
+		fi1, _ := os.Stat(path)
+		fi2, _ := os.Stat(other)
+		if os.SameFile(fi1, fi2) != tc.writeThrough {
+			t.Errorf("WriteThrough=%t: after Rewrite the links are the same file: %t", tc.writeThrough, !tc.writeThrough)
+		}
+		if fi1.Mode().Perm() != 0640 {
+			t.Errorf("WriteThrough=%t: after Rewrite mode = %v; want %v", tc.writeThrough, fi1.Mode().Perm(), os.FileMode(0640))
+		}
+	}
+}
+
+func TestRewriteFIFO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fifo")
+	if err := syscall.Mkfifo(path, 0644); err != nil {
+		t.Skip("can't make a FIFO:", err)
+	}
+	// Opening the FIFO would block, so a pass is not hanging here.
+	if _, err := Rewrite(path, upper, Options{}); err == nil {
+		t.Errorf("Rewrite of a FIFO didn't error")
+	}
+	assertOnlyFile(t, path)
+}
+
+func TestRewriteOwner(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("only root can give a file away")
+	}
+	path := writeFile(t, "a.txt", "foo\n", 0644)
+	if err := os.Chown(path, 1234, 5678); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Rewrite(path, upper, Options{Backup: ".bak"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{path, path + ".bak"} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		st := fi.Sys().(*syscall.Stat_t)
+		if st.Uid != 1234 || st.Gid != 5678 {
+			t.Errorf("after Rewrite %s is owned by %d:%d; want 1234:5678", filepath.Base(p), st.Uid, st.Gid)
+		}
+	}
+}
+
+func TestRewriteSetuid(t *testing.T) {
+	path := writeFile(t, "a.sh", "foo\n", 0755)
+	want := 0755 | os.ModeSetuid | os.ModeSetgid
+	if err := os.Chmod(path, want); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Rewrite(path, upper, Options{Backup: ".bak"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{path, path + ".bak"} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fi.Mode(); got != want {
+			t.Errorf("after Rewrite %s has mode %v; want %v", filepath.Base(p), got, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package textfile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SkipDirs names directories Walk never descends into.
+var SkipDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	".bzr":         true,
+	"_darcs":       true,
+	"CVS":          true,
+	"node_modules": true,
+}
+
+// Patterns is a list of glob patterns, see filepath.Match.  It
+// implements flag.Value, so a repeated flag adds to the list.
+type Patterns []string
+
+func (p *Patterns) String() string {
+	if p == nil {
+		return ""
+	}
+	return strings.Join(*p, ",")
+}
+
+func (p *Patterns) Set(s string) error {
+	if _, err := filepath.Match(s, ""); err != nil {
+		return err
+	}
+	*p = append(*p, s)
+	return nil
+}
+
+// Match reports whether any pattern matches either the base name
+// of path or all of path.  Path is slash-separated.
+func (p Patterns) Match(path string) bool {
+	base := filepath.Base(path)
+	for _, pat := range p {
+		if ok, _ := filepath.Match(pat, base); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pat, path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter selects files by their path relative to where a walk
+// started.  An empty Include selects everything.  Exclude wins
+// over Include, and also prunes whole directories.
+type Filter struct {
+	Include, Exclude Patterns
+
+	// FollowSymlinks selects symlinks to regular files, as well
+	// as regular files.  Symlinks to directories are never
+	// followed.
+	FollowSymlinks bool
+}
+
+func (f Filter) selects(rel string) bool {
+	if f.Exclude.Match(rel) {
+		return false
+	}
+	return len(f.Include) == 0 || f.Include.Match(rel)
+}
+
+// regular reports whether path, described by info, is a regular
+// file, or a symlink to one that f follows.
+func (f Filter) regular(path string, info os.FileInfo) bool {
+	if info.Mode()&fs.ModeSymlink != 0 && f.FollowSymlinks {
+		var err error
+		if info, err = os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return info.Mode().IsRegular()
+}
+
+// Walk returns the regular files under root that f selects, in
+// lexical order.  It does not descend into SkipDirs, or into
+// directories f excludes.  Skipped counts the files that were
+// passed over, either because f did not select them or because
+// they are not regular files: symlinks, unless f.FollowSymlinks,
+// FIFOs, devices and sockets.
+//
+// If root is not a directory it is returned as is.
+func (f Filter) Walk(root string) (files []string, skipped int, err error) {
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			if !info.IsDir() {
+				files = append(files, path)
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if info.IsDir() {
+			if SkipDirs[info.Name()] || f.Exclude.Match(rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !f.regular(path, info) || !f.selects(rel) {
+			skipped++
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	return files, skipped, err
+}
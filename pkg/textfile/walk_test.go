@@ -0,0 +1,162 @@
+package textfile
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"zacharysyoung/CLUtils/pkg/temptree"
+)
+
+var d, f = temptree.D, temptree.F
+
+func TestPatternsMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pats Patterns
+		path string
+		want bool
+	}{
+		{Patterns{"*.go"}, "main.go", true},
+		{Patterns{"*.go"}, "cmds/tree/tree.go", true},
+		{Patterns{"*.go"}, "go.mod", false},
+		{Patterns{"*.md", "*.txt"}, "docs/a.txt", true},
+		{Patterns{"cmds/*/main.go"}, "cmds/tree/main.go", true},
+		{Patterns{"cmds/*/main.go"}, "pkg/tree/main.go", false},
+		{Patterns{"vendor"}, "vendor", true},
+		{Patterns{}, "main.go", false},
+	} {
+		if got := tc.pats.Match(tc.path); got != tc.want {
+			t.Errorf("%v.Match(%s) = %t; want %t", tc.pats, tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestPatternsSet(t *testing.T) {
+	var p Patterns
+	for _, s := range []string{"*.go", "*.txt"} {
+		if err := p.Set(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := p.String(), "*.go,*.txt"; got != want {
+		t.Errorf("after Set got %s; want %s", got, want)
+	}
+
+	if err := p.Set("[bad"); err == nil {
+		t.Errorf("Set(%q) didn't error", "[bad")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	tree, prefix, err := temptree.NewTree(
+		f("a.txt"),
+		f("b.go"),
+		d(".git",
+			f("config")),
+		d("node_modules",
+			f("x.txt")),
+		d("src",
+			f("c.go"),
+			f("d.txt"),
+			d("vendor",
+				f("e.go"))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Remove()
+
+	for _, tc := range []struct {
+		filter      Filter
+		want        []string
+		wantSkipped int
+	}{
+		{
+			Filter{},
+			[]string{"a.txt", "b.go", "src/c.go", "src/d.txt", "src/vendor/e.go"},
+			0,
+		},
+		{
+			Filter{Include: Patterns{"*.go"}},
+			[]string{"b.go", "src/c.go", "src/vendor/e.go"},
+			2,
+		},
+		{
+			Filter{Include: Patterns{"*.go"}, Exclude: Patterns{"vendor"}},
+			[]string{"b.go", "src/c.go"},
+			2,
+		},
+		{
+			Filter{Exclude: Patterns{"src/*.txt"}},
+			[]string{"a.txt", "b.go", "src/c.go", "src/vendor/e.go"},
+			1,
+		},
+	} {
+		files, skipped, err := tc.filter.Walk(prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, len(files))
+		for i, path := range files {
+			rel, _ := filepath.Rel(prefix, path)
+			got[i] = filepath.ToSlash(rel)
+		}
+		if !reflect.DeepEqual(got, tc.want) || skipped != tc.wantSkipped {
+			t.Errorf("%+v.Walk()\n  got %v, %d skipped\n want %v, %d skipped", tc.filter, got, skipped, tc.want, tc.wantSkipped)
+		}
+	}
+}
+
+func TestWalkSymlinks(t *testing.T) {
+	tree, prefix, err := temptree.NewTree(
+		f("a.txt"),
+		temptree.L("b.txt", "a.txt"),
+		temptree.L("c.txt", "nowhere.txt"),
+		d("src",
+			f("d.txt")),
+		temptree.L("src2", "src"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Remove()
+
+	for _, tc := range []struct {
+		filter      Filter
+		want        []string
+		wantSkipped int
+	}{
+		{Filter{}, []string{"a.txt", "src/d.txt"}, 3},
+		{Filter{FollowSymlinks: true}, []string{"a.txt", "b.txt", "src/d.txt"}, 2},
+	} {
+		files, skipped, err := tc.filter.Walk(prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, len(files))
+		for i, path := range files {
+			rel, _ := filepath.Rel(prefix, path)
+			got[i] = filepath.ToSlash(rel)
+		}
+		if !reflect.DeepEqual(got, tc.want) || skipped != tc.wantSkipped {
+			t.Errorf("%+v.Walk()\n  got %v, %d skipped\n want %v, %d skipped", tc.filter, got, skipped, tc.want, tc.wantSkipped)
+		}
+	}
+}
+
+func TestWalkFile(t *testing.T) {
+	tree, prefix, err := temptree.NewTree(f("a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tree.Remove()
+
+	path := filepath.Join(prefix, "a.txt")
+	files, _, err := Filter{Include: Patterns{"*.go"}}.Walk(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{path}) {
+		t.Errorf("Walk(%s) = %v; want only the file itself", path, files)
+	}
+}